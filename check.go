@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"github.com/butlermatt/glpc/checker"
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+	"io/ioutil"
+	"os"
+)
+
+// runCheck type checks each file without running it and reports the syntax errors and type mismatches found. It
+// returns the exit status for the process.
+func runCheck(files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "no files to check")
+		return 1
+	}
+
+	status := 0
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading file: %+v\n", err)
+			status = 1
+			continue
+		}
+
+		p := parser.New(lexer.New(data, file))
+		stmts, _ := p.Parse()
+		if errs := p.Errors(); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "%s: [Syntax error] %+v\n", file, err)
+			}
+			status = 1
+			continue
+		}
+
+		for _, err := range checker.Check(stmts) {
+			fmt.Fprintf(os.Stderr, "%s: [Type error] %v\n", file, err)
+			status = 1
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,765 @@
+// Package checker statically checks the type annotations of a parsed program. Annotations are optional, so the
+// checker only reports mismatches it can be certain of: values whose types are unknown until the program runs are
+// accepted, and left to the checks the interpreter makes at runtime.
+package checker
+
+import (
+	"fmt"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
+
+// CheckError is a type mismatch found by the checker.
+type CheckError struct {
+	// Line is the line number the mismatch was found on.
+	Line int
+	// Where is the token the mismatch was found at.
+	Where string
+	// Msg describes the mismatch.
+	Msg string
+}
+
+func (ce CheckError) Error() string {
+	return fmt.Sprintf("On line %d: %s - %s", ce.Line, ce.Where, ce.Msg)
+}
+
+// staticType is the type of an expression as far as the checker can tell. A nil *staticType is unknown, and is
+// compatible with every other type.
+type staticType struct {
+	name     string // A builtin type name, "null", or the name of a class or trait.
+	nullable bool
+	class    *classInfo // The class or trait named, if any.
+}
+
+func (t *staticType) String() string {
+	if t.nullable {
+		return t.name + "?"
+	}
+	return t.name
+}
+
+// classInfo holds the declarations of a class or trait which the checker needs to check its uses.
+type classInfo struct {
+	decl   *object.ClassStmt
+	super  string
+	traits []string
+}
+
+// symbol is a name in scope: a variable of a type, a function, or a class.
+type symbol struct {
+	typ   *staticType
+	fn    *object.FunctionStmt
+	class *classInfo
+}
+
+// Checker walks a program checking the values given to typed variables, fields, parameters and return values.
+type Checker struct {
+	errors   []CheckError
+	classes  map[string]*classInfo
+	scopes   []map[string]*symbol
+	fns      []*object.FunctionStmt // Functions being checked, innermost last.
+	imported bool                   // Whether the program imports other files, which may declare unknown types.
+}
+
+// Check checks the statements of a parsed program and returns the mismatches found.
+func Check(stmts []object.Stmt) []CheckError {
+	c := &Checker{classes: make(map[string]*classInfo)}
+	c.begin()
+
+	// Top-level declarations may be used before they appear, so they are all known before checking begins.
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *object.ImportStmt:
+			c.imported = true
+		case *object.ClassStmt:
+			ci := &classInfo{decl: s}
+			if s.Super != nil {
+				ci.super = s.Super.Name.Lexeme
+			}
+			for _, t := range s.Traits {
+				ci.traits = append(ci.traits, t.Name.Lexeme)
+			}
+			c.classes[s.Name.Lexeme] = ci
+			c.define(s.Name.Lexeme, &symbol{class: ci})
+		case *object.TraitStmt:
+			ci := &classInfo{decl: &object.ClassStmt{Name: s.Name, Methods: s.Methods, Getters: s.Getters, Setters: s.Setters, Fields: s.Fields}}
+			c.classes[s.Name.Lexeme] = ci
+			c.define(s.Name.Lexeme, &symbol{class: ci})
+		case *object.FunctionStmt:
+			c.define(s.Name.Lexeme, &symbol{typ: &staticType{name: "fn"}, fn: s})
+		}
+	}
+
+	for _, stmt := range stmts {
+		c.stmt(stmt)
+	}
+	return c.errors
+}
+
+func (c *Checker) addError(token *lexer.Token, msg string) {
+	c.errors = append(c.errors, CheckError{Line: token.Line, Where: token.Lexeme, Msg: msg})
+}
+
+func (c *Checker) begin() {
+	c.scopes = append(c.scopes, make(map[string]*symbol))
+}
+
+func (c *Checker) end() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *Checker) define(name string, sym *symbol) {
+	c.scopes[len(c.scopes)-1][name] = sym
+}
+
+func (c *Checker) lookup(name string) *symbol {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if sym, ok := c.scopes[i][name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// annotation returns the type named by an annotation, reporting names which are neither builtin types nor classes
+// or traits of the program. any, and names which may be declared by an imported file, are unknown.
+func (c *Checker) annotation(t *object.TypeAnnotation) *staticType {
+	if t == nil {
+		return nil
+	}
+
+	typ := c.typeOf(t)
+	if typ == nil && t.Name.Lexeme != "any" && !c.imported {
+		c.addError(t.Name, "Unknown type "+t.Name.Lexeme+".")
+	}
+	return typ
+}
+
+// typeOf returns the type named by an annotation without reporting unknown names.
+func (c *Checker) typeOf(t *object.TypeAnnotation) *staticType {
+	if t == nil || t.Name.Lexeme == "any" {
+		return nil
+	}
+	if object.BuiltinTypes[t.Name.Lexeme] {
+		return &staticType{name: t.Name.Lexeme, nullable: t.Nullable}
+	}
+	if ci, ok := c.classes[t.Name.Lexeme]; ok {
+		return &staticType{name: t.Name.Lexeme, nullable: t.Nullable, class: ci}
+	}
+	return nil
+}
+
+// check reports a mismatch at tok if a value of type value cannot be given to what, which has type want.
+func (c *Checker) check(tok *lexer.Token, want, value *staticType, what string) {
+	if !c.assignable(value, want) {
+		c.addError(tok, what+" must be of type "+want.String()+", got "+value.name+".")
+	}
+}
+
+// assignable reports whether a value of type from may be held by something of type to. It is only false when the
+// value is certain not to be accepted.
+func (c *Checker) assignable(from, to *staticType) bool {
+	switch {
+	case from == nil || to == nil:
+		return true
+	case from.name == "null":
+		return to.nullable
+	case from.name == to.name:
+		return true
+	case to.name == "num":
+		return from.name == "int" || from.name == "float"
+	case from.name == "num":
+		// A num may hold either kind of number.
+		return to.name == "int" || to.name == "float"
+	case from.class != nil && to.class != nil:
+		return c.isA(from.class, to.class, make(map[*classInfo]bool))
+	}
+	return false
+}
+
+// isA reports whether instances of ci may be instances of target, through superclasses and traits. A class which
+// inherits from one the checker does not know about may be anything.
+func (c *Checker) isA(ci, target *classInfo, seen map[*classInfo]bool) bool {
+	if ci == target {
+		return true
+	}
+	if seen[ci] {
+		return false
+	}
+	seen[ci] = true
+
+	for _, name := range append([]string{ci.super}, ci.traits...) {
+		if name == "" {
+			continue
+		}
+		parent, ok := c.classes[name]
+		if !ok || c.isA(parent, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMethod returns the method, getter or setter name of ci or the classes and traits it inherits from.
+func (c *Checker) findMethod(ci *classInfo, name string, members func(*object.ClassStmt) []*object.FunctionStmt) *object.FunctionStmt {
+	seen := make(map[*classInfo]bool)
+	var find func(ci *classInfo) *object.FunctionStmt
+	find = func(ci *classInfo) *object.FunctionStmt {
+		if ci == nil || seen[ci] {
+			return nil
+		}
+		seen[ci] = true
+
+		for _, fn := range members(ci.decl) {
+			if fn.Name.Lexeme == name {
+				return fn
+			}
+		}
+		for _, t := range ci.traits {
+			if fn := find(c.classes[t]); fn != nil {
+				return fn
+			}
+		}
+		return find(c.classes[ci.super])
+	}
+	return find(ci)
+}
+
+// findField returns the declaration of the instance field, or static field, name of ci or the classes and traits it
+// inherits from.
+func (c *Checker) findField(ci *classInfo, name string, static bool) *object.VarStmt {
+	seen := make(map[*classInfo]bool)
+	var find func(ci *classInfo) *object.VarStmt
+	find = func(ci *classInfo) *object.VarStmt {
+		if ci == nil || seen[ci] {
+			return nil
+		}
+		seen[ci] = true
+
+		fields := ci.decl.Fields
+		if static {
+			fields = ci.decl.StaticFields
+		}
+		for _, field := range fields {
+			if field.Name.Lexeme == name {
+				return field
+			}
+		}
+		for _, t := range ci.traits {
+			if field := find(c.classes[t]); field != nil {
+				return field
+			}
+		}
+		return find(c.classes[ci.super])
+	}
+	return find(ci)
+}
+
+func methods(cs *object.ClassStmt) []*object.FunctionStmt       { return cs.Methods }
+func getters(cs *object.ClassStmt) []*object.FunctionStmt       { return cs.Getters }
+func setters(cs *object.ClassStmt) []*object.FunctionStmt       { return cs.Setters }
+func staticMethods(cs *object.ClassStmt) []*object.FunctionStmt { return cs.StaticMethods }
+
+func (c *Checker) stmt(stmt object.Stmt) {
+	switch s := stmt.(type) {
+	case *object.BlockStmt:
+		c.begin()
+		for _, st := range s.Statements {
+			c.stmt(st)
+		}
+		c.end()
+	case *object.ClassStmt:
+		c.class(s, c.classes[s.Name.Lexeme])
+	case *object.TraitStmt:
+		ci := c.classes[s.Name.Lexeme]
+		if ci == nil {
+			ci = &classInfo{decl: &object.ClassStmt{Name: s.Name, Methods: s.Methods, Getters: s.Getters, Setters: s.Setters, Fields: s.Fields}}
+		}
+		c.class(ci.decl, ci)
+	case *object.ExpressionStmt:
+		c.expr(s.Expression)
+	case *object.FunctionStmt:
+		c.define(s.Name.Lexeme, &symbol{typ: &staticType{name: "fn"}, fn: s})
+		c.function(s)
+	case *object.IfStmt:
+		c.expr(s.Condition)
+		c.stmt(s.Then)
+		if s.Else != nil {
+			c.stmt(s.Else)
+		}
+	case *object.DestructureStmt:
+		c.expr(s.Value)
+		for _, name := range s.Names {
+			c.define(name.Lexeme, &symbol{})
+		}
+		if s.Rest != nil {
+			c.define(s.Rest.Lexeme, &symbol{typ: &staticType{name: "list"}})
+		}
+	case *object.MultiAssignStmt:
+		values := make([]*staticType, len(s.Values))
+		for i, value := range s.Values {
+			values[i] = c.expr(value)
+		}
+		// The elements of a single list value are not known.
+		for i, target := range s.Targets {
+			switch t := target.(type) {
+			case *object.AssignExpr:
+				if len(values) == len(s.Targets) {
+					c.check(t.Name, c.typeOf(t.Type), values[i], "Variable "+t.Name.Lexeme)
+				}
+			case *object.SetExpr:
+				c.expr(t.Object)
+			}
+		}
+	case *object.MatchStmt:
+		c.expr(s.Subject)
+		for _, mc := range s.Cases {
+			c.begin()
+			for _, pat := range mc.Patterns {
+				c.pattern(pat)
+			}
+			if mc.Guard != nil {
+				c.expr(mc.Guard)
+			}
+			c.stmt(mc.Body)
+			c.end()
+		}
+	case *object.ForStmt:
+		c.begin()
+		if s.Initializer != nil {
+			c.stmt(s.Initializer)
+		}
+		c.expr(s.Condition)
+		c.stmt(s.Body)
+		if s.Increment != nil {
+			c.expr(s.Increment)
+		}
+		c.end()
+	case *object.ForInStmt:
+		c.expr(s.Iterable)
+		c.begin()
+		c.define(s.Name.Lexeme, &symbol{})
+		c.stmt(s.Body)
+		c.end()
+	case *object.ReturnStmt:
+		value := c.expr(s.Value)
+		if len(c.fns) > 0 {
+			fn := c.fns[len(c.fns)-1]
+			c.check(s.Keyword, c.typeOf(fn.ReturnType), value, "Return value of "+fn.Name.Lexeme)
+		}
+	case *object.VarStmt:
+		want := c.annotation(s.Type)
+		if s.Value != nil {
+			c.check(s.Name, want, c.expr(s.Value), "Variable "+s.Name.Lexeme)
+		}
+		c.define(s.Name.Lexeme, &symbol{typ: want})
+	}
+}
+
+// pattern defines the variables bound by a pattern of a match case. A variable bound by a type pattern has that type,
+// and the rest of a list pattern is a list.
+func (c *Checker) pattern(pat *object.Pattern) {
+	switch pat.Kind {
+	case object.BindPattern:
+		c.define(pat.Name.Lexeme, &symbol{})
+	case object.TypePattern:
+		c.define(pat.Name.Lexeme, &symbol{typ: c.annotation(pat.Type)})
+	case object.ListPattern:
+		for _, elem := range pat.Elements {
+			c.pattern(elem)
+		}
+		if pat.Rest != nil {
+			c.define(pat.Rest.Lexeme, &symbol{typ: &staticType{name: "list"}})
+		}
+	}
+}
+
+// class checks the members of a class or trait. Within instance members this has the type of the class.
+func (c *Checker) class(cs *object.ClassStmt, ci *classInfo) {
+	if ci == nil {
+		ci = &classInfo{decl: cs}
+	}
+	c.define(cs.Name.Lexeme, &symbol{class: ci})
+
+	c.begin()
+	c.define("this", &symbol{typ: &staticType{name: cs.Name.Lexeme, class: ci}})
+	for _, field := range cs.Fields {
+		c.field(field)
+	}
+	for _, group := range [][]*object.FunctionStmt{cs.Methods, cs.Getters, cs.Setters} {
+		for _, fn := range group {
+			c.function(fn)
+		}
+	}
+	c.end()
+
+	// Static members are called on the class, so the type of this is not known.
+	c.begin()
+	c.define("this", &symbol{})
+	for _, field := range cs.StaticFields {
+		c.field(field)
+	}
+	for _, fn := range cs.StaticMethods {
+		c.function(fn)
+	}
+	c.end()
+}
+
+func (c *Checker) field(field *object.VarStmt) {
+	want := c.annotation(field.Type)
+	if field.Value != nil {
+		c.check(field.Name, want, c.expr(field.Value), "Field "+field.Name.Lexeme)
+	}
+}
+
+// function checks the body of a function with its parameters in scope.
+func (c *Checker) function(fn *object.FunctionStmt) {
+	c.begin()
+	for i, param := range fn.Parameters {
+		var typ *staticType
+		if fn.ParamTypes != nil {
+			typ = c.annotation(fn.ParamTypes[i])
+		}
+		if fn.Defaults != nil && fn.Defaults[i] != nil {
+			c.check(param, typ, c.expr(fn.Defaults[i]), "Parameter "+param.Lexeme)
+		}
+		// The arguments collected by a rest parameter have its type, and it holds them in a list.
+		if fn.Variadic && i == len(fn.Parameters)-1 {
+			typ = &staticType{name: "list"}
+		}
+		c.define(param.Lexeme, &symbol{typ: typ})
+	}
+	c.annotation(fn.ReturnType)
+
+	c.fns = append(c.fns, fn)
+	for _, st := range fn.Body {
+		c.stmt(st)
+	}
+	c.fns = c.fns[:len(c.fns)-1]
+	c.end()
+}
+
+// call checks the arguments of a call to fn against its parameters, and returns the type it returns. names holds
+// the names of named arguments, and is nil when there are none.
+func (c *Checker) call(paren *lexer.Token, fn *object.FunctionStmt, args []*staticType, names []*lexer.Token) *staticType {
+	fixed := len(fn.Parameters)
+	if fn.Variadic {
+		fixed--
+	}
+	required := 0
+	for i := 0; i < fixed; i++ {
+		if fn.Defaults == nil || fn.Defaults[i] == nil {
+			required++
+		}
+	}
+
+	// Work out which parameter receives each argument. Named arguments are checked by the interpreter when they do
+	// not name a parameter.
+	params := make([]int, len(args))
+	given := make(map[int]bool)
+	positional := 0
+	for i := range args {
+		params[i] = -1
+		if names == nil || names[i] == nil {
+			positional++
+			params[i] = i
+			if i >= fixed {
+				params[i] = fixed
+			}
+			given[params[i]] = true
+			continue
+		}
+		for j, param := range fn.Parameters[:fixed] {
+			if param.Lexeme == names[i].Lexeme {
+				params[i] = j
+				given[j] = true
+			}
+		}
+	}
+
+	if positional > fixed && !fn.Variadic || names == nil && positional < required {
+		c.addError(paren, arityMessage(required, fixed, fn.Variadic, len(args)))
+		return c.returnType(fn)
+	}
+	for i := 0; i < fixed && names != nil; i++ {
+		if !given[i] && (fn.Defaults == nil || fn.Defaults[i] == nil) {
+			c.addError(paren, "Missing argument for parameter "+fn.Parameters[i].Lexeme+".")
+		}
+	}
+
+	if fn.ParamTypes != nil {
+		for i, p := range params {
+			if p == -1 {
+				continue
+			}
+			param := fn.Parameters[p]
+			c.check(paren, c.typeOf(fn.ParamTypes[p]), args[i], "Parameter "+param.Lexeme+" of "+fn.Name.Lexeme)
+		}
+	}
+	return c.returnType(fn)
+}
+
+// returnType returns the type of the value a call to fn returns. Calling a generator returns the generator, and its
+// return type is that of the value it finally returns.
+func (c *Checker) returnType(fn *object.FunctionStmt) *staticType {
+	if fn.Generator {
+		return &staticType{name: "generator"}
+	}
+	return c.typeOf(fn.ReturnType)
+}
+
+// arityMessage describes a call with the wrong number of arguments, as the interpreter does.
+func arityMessage(min, max int, variadic bool, got int) string {
+	switch {
+	case variadic:
+		return fmt.Sprintf("Expected at least %d arguments but got %d.", min, got)
+	case min != max:
+		return fmt.Sprintf("Expected %d to %d arguments but got %d.", min, max, got)
+	}
+	return fmt.Sprintf("Expected %d arguments but got %d.", max, got)
+}
+
+// expr checks an expression and returns its type, or nil if it cannot be known.
+func (c *Checker) expr(expr object.Expr) *staticType {
+	switch e := expr.(type) {
+	case *object.AssignExpr:
+		value := c.expr(e.Value)
+		c.check(e.Name, c.typeOf(e.Type), value, "Variable "+e.Name.Lexeme)
+		return value
+	case *object.BinaryExpr:
+		return binaryType(e.Operator, c.expr(e.Left), c.expr(e.Right))
+	case *object.BooleanExpr:
+		return &staticType{name: "bool"}
+	case *object.CallExpr:
+		return c.callExpr(e)
+	case *object.ConditionalExpr:
+		c.expr(e.Condition)
+		then, otherwise := c.expr(e.Then), c.expr(e.Else)
+		if then != nil && otherwise != nil && then.name == otherwise.name {
+			return &staticType{name: then.name, nullable: then.nullable || otherwise.nullable, class: then.class}
+		}
+	case *object.GetExpr:
+		return c.getExpr(e)
+	case *object.GroupingExpr:
+		return c.expr(e.Expression)
+	case *object.IndexExpr:
+		c.expr(e.Left)
+		c.expr(e.Right)
+	case *object.InterpolationExpr:
+		for _, part := range e.Parts {
+			c.expr(part)
+		}
+		return &staticType{name: "string"}
+	case *object.ListExpr:
+		for _, v := range e.Values {
+			c.expr(v)
+		}
+		return &staticType{name: "list"}
+	case *object.LogicalExpr:
+		c.expr(e.Left)
+		c.expr(e.Right)
+	case *object.NumberExpr:
+		if e.Token.Type == lexer.NumberF {
+			return &staticType{name: "float"}
+		}
+		return &staticType{name: "int"}
+	case *object.NullExpr:
+		return &staticType{name: "null"}
+	case *object.OptionalExpr:
+		// The chain may be skipped, so its value may be null whatever its type.
+		c.expr(e.Expression)
+	case *object.SetExpr:
+		return c.setExpr(e)
+	case *object.StringExpr:
+		return &staticType{name: "string"}
+	case *object.ThisExpr:
+		if sym := c.lookup("this"); sym != nil {
+			return sym.typ
+		}
+	case *object.UpdateExpr:
+		return c.expr(e.Assign)
+	case *object.UnaryExpr:
+		right := c.expr(e.Right)
+		if e.Operator.Type == lexer.Bang {
+			return &staticType{name: "bool"}
+		}
+		if e.Operator.Type == lexer.Tild {
+			if right != nil && right.name == "int" {
+				return right
+			}
+			return nil
+		}
+		if right != nil && (right.name == "int" || right.name == "float" || right.name == "num") {
+			return right
+		}
+	case *object.VariableExpr:
+		if sym := c.lookup(e.Name.Lexeme); sym != nil {
+			return sym.typ
+		}
+	case *object.SpawnExpr:
+		c.callExpr(e.Call)
+		return &staticType{name: "task"}
+	case *object.YieldExpr:
+		// The value a yield is resumed with is not known.
+		c.expr(e.Value)
+	}
+	return nil
+}
+
+func (c *Checker) callExpr(e *object.CallExpr) *staticType {
+	args := make([]*staticType, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = c.expr(arg)
+	}
+
+	switch callee := e.Callee.(type) {
+	case *object.VariableExpr:
+		sym := c.lookup(callee.Name.Lexeme)
+		switch {
+		case sym == nil:
+		case sym.fn != nil:
+			return c.call(e.Paren, sym.fn, args, e.Names)
+		case sym.class != nil:
+			// Only the initializer declared by the class itself is called.
+			init := &object.FunctionStmt{Name: callee.Name}
+			for _, fn := range sym.class.decl.Methods {
+				if fn.Name.Lexeme == "init" {
+					init = fn
+				}
+			}
+			c.call(e.Paren, init, args, e.Names)
+			return &staticType{name: callee.Name.Lexeme, class: sym.class}
+		}
+	case *object.GetExpr:
+		if v, ok := callee.Object.(*object.VariableExpr); ok {
+			if sym := c.lookup(v.Name.Lexeme); sym != nil && sym.class != nil {
+				if fn := c.findMethod(sym.class, callee.Name.Lexeme, staticMethods); fn != nil {
+					return c.call(e.Paren, fn, args, e.Names)
+				}
+				return nil
+			}
+		}
+		if recv := c.expr(callee.Object); recv != nil && recv.class != nil {
+			if fn := c.findMethod(recv.class, callee.Name.Lexeme, methods); fn != nil {
+				return c.call(e.Paren, fn, args, e.Names)
+			}
+		}
+		return nil
+	}
+
+	c.expr(e.Callee)
+	return nil
+}
+
+func (c *Checker) getExpr(e *object.GetExpr) *staticType {
+	if v, ok := e.Object.(*object.VariableExpr); ok {
+		if sym := c.lookup(v.Name.Lexeme); sym != nil && sym.class != nil {
+			if field := c.findField(sym.class, e.Name.Lexeme, true); field != nil {
+				return c.typeOf(field.Type)
+			}
+			return nil
+		}
+	}
+
+	recv := c.expr(e.Object)
+	if recv == nil || recv.class == nil {
+		return nil
+	}
+	if getter := c.findMethod(recv.class, e.Name.Lexeme, getters); getter != nil {
+		return c.typeOf(getter.ReturnType)
+	}
+	if field := c.findField(recv.class, e.Name.Lexeme, false); field != nil {
+		return c.typeOf(field.Type)
+	}
+	if c.findMethod(recv.class, e.Name.Lexeme, methods) != nil {
+		return &staticType{name: "fn"}
+	}
+	return nil
+}
+
+func (c *Checker) setExpr(e *object.SetExpr) *staticType {
+	value := c.expr(e.Value)
+	if e.IsIndex {
+		c.expr(e.Object)
+		return value
+	}
+
+	if v, ok := e.Object.(*object.VariableExpr); ok {
+		if sym := c.lookup(v.Name.Lexeme); sym != nil && sym.class != nil {
+			if field := c.findField(sym.class, e.Name.Lexeme, true); field != nil {
+				c.check(e.Name, c.typeOf(field.Type), value, "Field "+e.Name.Lexeme)
+			}
+			return value
+		}
+	}
+
+	recv := c.expr(e.Object)
+	if recv == nil || recv.class == nil {
+		return value
+	}
+	if setter := c.findMethod(recv.class, e.Name.Lexeme, setters); setter != nil {
+		if setter.ParamTypes != nil && len(setter.ParamTypes) == 1 {
+			c.check(e.Name, c.typeOf(setter.ParamTypes[0]), value, "Parameter "+setter.Parameters[0].Lexeme+" of "+setter.Name.Lexeme)
+		}
+	} else if field := c.findField(recv.class, e.Name.Lexeme, false); field != nil {
+		c.check(e.Name, c.typeOf(field.Type), value, "Field "+e.Name.Lexeme)
+	}
+	return value
+}
+
+// binaryType returns the type of the result of a binary operator applied to builtin types. Instances may overload
+// operators, so their results are unknown.
+func binaryType(oper *lexer.Token, left, right *staticType) *staticType {
+	if left == nil || right == nil {
+		if oper.Type == lexer.EqualEq || oper.Type == lexer.BangEq {
+			return &staticType{name: "bool"}
+		}
+		return nil
+	}
+
+	numeric := func(t *staticType) bool { return t.name == "int" || t.name == "float" || t.name == "num" }
+	switch oper.Type {
+	case lexer.EqualEq, lexer.BangEq:
+		return &staticType{name: "bool"}
+	case lexer.Less, lexer.LessEq, lexer.Greater, lexer.GreaterEq:
+		if numeric(left) && numeric(right) {
+			return &staticType{name: "bool"}
+		}
+	case lexer.Plus, lexer.Minus, lexer.Star, lexer.Percent:
+		if oper.Type == lexer.Plus && left.name == "string" && right.name == "string" {
+			return &staticType{name: "string"}
+		}
+		if !numeric(left) || !numeric(right) {
+			return nil
+		}
+		if left.name == "int" && right.name == "int" {
+			return &staticType{name: "int"}
+		}
+		if left.name == "float" || right.name == "float" {
+			return &staticType{name: "float"}
+		}
+		return &staticType{name: "num"}
+	case lexer.Slash:
+		if numeric(left) && numeric(right) {
+			return &staticType{name: "num"}
+		}
+	case lexer.TildSlash:
+		if numeric(left) && numeric(right) {
+			return &staticType{name: "int"}
+		}
+	case lexer.StarStar:
+		// A negative int power is a float.
+		if left.name == "float" || right.name == "float" {
+			return &staticType{name: "float"}
+		}
+		if numeric(left) && numeric(right) {
+			return &staticType{name: "num"}
+		}
+	case lexer.Amp, lexer.Pipe, lexer.Caret, lexer.LessLess, lexer.GreaterGreater:
+		if left.name == "int" && right.name == "int" {
+			return &staticType{name: "int"}
+		}
+	}
+	return nil
+}
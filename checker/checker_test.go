@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		where string
+		msg   string
+	}{
+		{"var x: int = 1;", 0, "", ""},
+		{"var x: int = 1.5;", 1, "x", "Variable x must be of type int, got float."},
+		{"var x: num = 1.5;", 0, "", ""},
+		{"var x: string = null;", 1, "x", "Variable x must be of type string, got null."},
+		{"var x: string? = null;", 0, "", ""},
+		{"var x: any = [];", 0, "", ""},
+		{"var x: Mob;", 1, "Mob", "Unknown type Mob."},
+		{"fn f(a) { var x: int = a; }", 0, "", ""},
+		{"fn f() { var x: int = 1; x = \"s\"; }", 1, "x", "Variable x must be of type int, got string."},
+		{"fn f(): bool { return 1 < 2; }", 0, "", ""},
+		{"fn f(): bool {\n return 1 + 2; }", 2, "return", "Return value of f must be of type bool, got int."},
+		{"fn f(): int { return; }", 1, "return", "Return value of f must be of type int, got null."},
+		{"fn f(a: int) {}\nfn g() { f(\"s\"); }", 2, ")", "Parameter a of f must be of type int, got string."},
+		{"fn f(a: int) {}\nfn g() { f(1, 2); }", 2, ")", "Expected 1 arguments but got 2."},
+		{"fn g(): int { return f(); }\nfn f(): string { return \"s\"; }", 1, "return", "Return value of g must be of type int, got string."},
+		{"class A {}\nclass B : A {}\nvar a: A = B();", 0, "", ""},
+		{"class A {}\nclass B {}\nvar a: A = B();", 3, "a", "Variable a must be of type A, got B."},
+		{"trait T {}\nclass A with T {}\nvar t: T = A();", 0, "", ""},
+		{"class A { init(n: int) {} }\nvar a = A(\"s\");", 2, ")", "Parameter n of init must be of type int, got string."},
+		{"class A { var hp: int = \"s\"; }", 1, "hp", "Field hp must be of type int, got string."},
+		{"class A { var hp: int; }\nfn f(a: A) { a.hp = 1.5; }", 2, "hp", "Field hp must be of type int, got float."},
+		{"class A { hit(d: int): bool { return true; } }\nfn f(a: A) { var s: string = a.hit(1); }", 2, "s", "Variable s must be of type string, got bool."},
+		{"class A { get hp(): int { return 1; } }\nfn f(a: A) { var s: string = a.hp; }", 2, "s", "Variable s must be of type string, got int."},
+		{"class A { static var n: int = 0; }\nfn f() { A.n = \"s\"; }", 2, "n", "Field n must be of type int, got string."},
+		{"class A { init() { this.hp = 1; } var hp: string; }", 1, "hp", "Field hp must be of type string, got int."},
+		{`import "lib.glpc"; var m: Mob = 1;`, 0, "", ""},
+		{"fn f(a: int = \"s\") {}", 1, "a", "Parameter a must be of type int, got string."},
+		{"fn f(a, b = 1) {}\nfn g() { f(1); f(1, 2); }", 0, "", ""},
+		{"fn f(a, b = 1) {}\nfn g() { f(); }", 2, ")", "Expected 1 to 2 arguments but got 0."},
+		{"fn f(a, ...r: int) {}\nfn g() { f(1, 2, 3); }", 0, "", ""},
+		{"fn f(a, ...r: int) {}\nfn g() { f(1, 2, 3.5); }", 2, ")", "Parameter r of f must be of type int, got float."},
+		{"fn f(a, ...r) { var l: list = r; }", 0, "", ""},
+		{"fn f(a, b: int = 1, c = 2) {}\nfn g() { f(c: 3, a: 1); }", 0, "", ""},
+		{"fn f(a, b: int = 1) {}\nfn g() { f(b: 2); }", 2, ")", "Missing argument for parameter a."},
+		{"fn f(a, b: int = 1) {}\nfn g() { f(1, b: \"s\"); }", 2, ")", "Parameter b of f must be of type int, got string."},
+		{"fn f(x) { match (x) { case int n => { var s: string = n; } } }", 1, "s", "Variable s must be of type string, got int."},
+		{"fn f(x) { match (x) { case [a, ...r] => { var l: list = r; var s: string = a; } } }", 0, "", ""},
+		{"fn f(x) { match (x) { case Mob m => f(m); } }", 1, "Mob", "Unknown type Mob."},
+		{"fn f() { var a: int = 1; var b = 2; a, b = \"s\", b; }", 1, "a", "Variable a must be of type int, got string."},
+		{"fn f(l) { var a: int = 1; var b = 2; a, b = l; var [x, ...r] = l; var s: list = r; }", 0, "", ""},
+		{"var x: int = 1 << 2 | ~3; var y: num = 2 ** 3;", 0, "", ""},
+		{"fn f() { var i: int = 0; var s: string = i++; }", 1, "s", "Variable s must be of type string, got int."},
+		{"fn gen(): int { yield 1; return 2; } fn f() { var g: generator = gen(); for (var x in g) { var y: int = x; } }", 0, "", ""},
+		{"fn gen() { yield 1; } fn f() { var n: int = gen(); }", 1, "n", "Variable n must be of type int, got generator."},
+		{"fn work(n: int) {} fn f() { var t: task = spawn work(1); var n: int = spawn work(2); }", 1, "n", "Variable n must be of type int, got task."},
+	}
+
+	for i, tt := range tests {
+		p := parser.New(lexer.New([]byte(tt.input), "testfile.gpc"))
+		stmts, _ := p.Parse()
+		if errs := p.Errors(); len(errs) > 0 {
+			t.Errorf("test %d: parser errors: %v", i+1, errs)
+			continue
+		}
+
+		errs := Check(stmts)
+		if tt.msg == "" {
+			if len(errs) > 0 {
+				t.Errorf("test %d: unexpected errors: %v", i+1, errs)
+			}
+			continue
+		}
+
+		if len(errs) != 1 {
+			t.Errorf("test %d: wrong number of errors. expected=1, got=%d %v", i+1, len(errs), errs)
+			continue
+		}
+		e := errs[0]
+		if e.Line != tt.line || e.Where != tt.where || e.Msg != tt.msg {
+			t.Errorf("test %d: wrong error. expected=%d %q %q, got=%d %q %q", i+1, tt.line, tt.where, tt.msg, e.Line, e.Where, e.Msg)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
+
+// AssertionError is returned by the assert builtins when an assertion does not hold. Token is filled in with the
+// location of the failing call when the error passes back through the call expression.
+type AssertionError struct {
+	Token   *lexer.Token
+	Message string
+}
+
+func (ae *AssertionError) Error() string {
+	if ae.Token == nil {
+		return "[Assertion failed] " + ae.Message
+	}
+	return fmt.Sprintf("[Assertion failed] %s:%d - %s", ae.Token.Filename, ae.Token.Line, ae.Message)
+}
+
+func newAssertionError(args []object.Object, msgIndex int, format string, a ...interface{}) *AssertionError {
+	msg := fmt.Sprintf(format, a...)
+	if len(args) > msgIndex {
+		msg = args[msgIndex].String() + ": " + msg
+	}
+	return &AssertionError{Message: msg}
+}
+
+// bAssert fails when its first argument is not truthy. An optional second argument is prepended to the message.
+func bAssert(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'assert' expects a condition and an optional message.")
+	}
+
+	if !isTruthy(args[0]) {
+		return NullOb, newAssertionError(args, 1, "expected a true value but got %s", interp.repr(args[0]))
+	}
+
+	return NullOb, nil
+}
+
+// bAssertEqual fails when the expected value (first argument) does not equal the actual value (second argument).
+func bAssertEqual(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return NullOb, BIError("'assertEqual' expects an expected value, an actual value and an optional message.")
+	}
+
+	eq, err := interp.valuesEqual(args[0], args[1])
+	if err != nil {
+		return NullOb, err
+	}
+
+	if !eq {
+		return NullOb, newAssertionError(args, 2, "expected %s but got %s", interp.repr(args[0]), interp.repr(args[1]))
+	}
+
+	return NullOb, nil
+}
+
+// bAssertThrows calls its first argument with no arguments and fails if it completes without a runtime error.
+// The message of the error that was raised is returned so that scripts may inspect it.
+func bAssertThrows(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'assertThrows' expects a function and an optional message.")
+	}
+
+	fn, ok := args[0].(Callable)
+	if !ok {
+		return NullOb, BIError("'assertThrows' argument must be a function.")
+	}
+	if min, _ := arityRange(fn); min > 0 {
+		return NullOb, BIError("'assertThrows' function must not require any arguments.")
+	}
+
+	_, err := fn.Call(interp, nil)
+	if err == nil {
+		return NullOb, newAssertionError(args, 1, "expected %s to throw an error", args[0].String())
+	}
+
+	return &String{Value: err.Error()}, nil
+}
+
+// valuesEqual compares two values for the assert builtins. Lists are compared element by element.
+func (interp *Interpreter) valuesEqual(left, right object.Object) (bool, error) {
+	if left.Type() == object.List && right.Type() == object.List {
+		l := left.(*List)
+		r := right.(*List)
+		if len(l.Elements) != len(r.Elements) {
+			return false, nil
+		}
+
+		for i := range l.Elements {
+			eq, err := interp.valuesEqual(l.Elements[i], r.Elements[i])
+			if err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return interp.equal(nil, left, right)
+}
+
+// repr returns the string form of a value as it would be written in a script, quoting strings. Instances are shown
+// as they would be printed.
+func (interp *Interpreter) repr(obj object.Object) string {
+	if obj.Type() == object.String {
+		return strconv.Quote(obj.String())
+	}
+	if s, err := interp.stringify(nil, obj); err == nil {
+		return s
+	}
+	return obj.String()
+}
@@ -3,6 +3,8 @@ package interpreter
 import (
 	"fmt"
 	"github.com/butlermatt/glpc/object"
+	"strings"
+	"unicode/utf8"
 )
 
 type CallFn func(interpreter *Interpreter, args []object.Object) (object.Object, error)
@@ -36,7 +38,46 @@ func SetupGlobal(env *object.Environment) *object.Environment {
 	}
 
 	env.DefineString("len", newBuiltin(1, bLen))
+	env.DefineString("slice", newBuiltin(-1, bSlice))
+	env.DefineString("chars", newBuiltin(1, bChars))
+	env.DefineString("bytes", newBuiltin(1, bBytes))
+	env.DefineString("contains", newBuiltin(2, bContains))
+	env.DefineString("freeze", newBuiltin(1, bFreeze))
+	env.DefineString("isFrozen", newBuiltin(1, bIsFrozen))
+	env.DefineString("next", newBuiltin(-1, bNext))
+	env.DefineString("isDone", newBuiltin(1, bIsDone))
+	env.DefineString("channel", newBuiltin(-1, bChannel))
+	env.DefineString("send", newBuiltin(2, bSend))
+	env.DefineString("recv", newBuiltin(1, bRecv))
+	env.DefineString("close", newBuiltin(1, bClose))
+	env.DefineString("select", newBuiltin(-1, bSelect))
+	env.DefineString("join", newBuiltin(1, bJoin))
+	env.DefineString("on", newBuiltin(-1, bOn))
+	env.DefineString("off", newBuiltin(1, bOff))
+	env.DefineString("emit", newBuiltin(-1, bEmit))
+	env.DefineString("after", newBuiltin(2, bAfter))
+	env.DefineString("every", newBuiltin(2, bEvery))
+	env.DefineString("cancel", newBuiltin(1, bCancel))
+	env.DefineString("now", newBuiltin(0, bNow))
+	env.DefineString("advance", newBuiltin(1, bAdvance))
+	env.DefineString("jsonEncode", newBuiltin(-1, bJSONEncode))
+	env.DefineString("jsonDecode", newBuiltin(1, bJSONDecode))
+	env.DefineString("typeOf", newBuiltin(1, bTypeOf))
+	env.DefineString("classOf", newBuiltin(1, bClassOf))
+	env.DefineString("isInstance", newBuiltin(2, bIsInstance))
+	env.DefineString("fields", newBuiltin(1, bFields))
+	env.DefineString("methods", newBuiltin(1, bMethods))
+	env.DefineString("hasField", newBuiltin(2, bHasField))
+	env.DefineString("hasMethod", newBuiltin(2, bHasMethod))
+	env.DefineString("getField", newBuiltin(2, bGetField))
+	env.DefineString("setField", newBuiltin(3, bSetField))
+	env.DefineString("arity", newBuiltin(1, bArity))
 	env.DefineString("debugPrint", newBuiltin(-1, bDebugPrint))
+	env.DefineString("assert", newBuiltin(-1, bAssert))
+	env.DefineString("assertEqual", newBuiltin(-1, bAssertEqual))
+	env.DefineString("assertThrows", newBuiltin(-1, bAssertThrows))
+	env.DefineString("math", newMathModule())
+	env.DefineString("random", newRandomModule())
 
 	return env
 }
@@ -45,7 +86,7 @@ func bLen(interp *Interpreter, args []object.Object) (object.Object, error) {
 	switch obj := args[0]; obj.Type() {
 	case object.String:
 		s := obj.(*String)
-		return &Number{IsInt: true, Int: len(s.Value)}, nil
+		return &Number{IsInt: true, Int: utf8.RuneCountInString(s.Value)}, nil
 	case object.List:
 		l := obj.(*List)
 		return &Number{IsInt: true, Int: len(l.Elements)}, nil
@@ -54,17 +95,181 @@ func bLen(interp *Interpreter, args []object.Object) (object.Object, error) {
 	return NullOb, BIError("'len' argument must be of a type STRING or LIST.")
 }
 
+// bFreeze makes a list or instance immutable, along with every list and instance it holds, and returns it. Other
+// values are already immutable and are returned unchanged.
+func bFreeze(interp *Interpreter, args []object.Object) (object.Object, error) {
+	freeze(args[0])
+	return args[0], nil
+}
+
+func freeze(obj object.Object) {
+	switch obj := obj.(type) {
+	case *List:
+		if obj.frozen {
+			return
+		}
+		obj.frozen = true
+		for _, el := range obj.Elements {
+			freeze(el)
+		}
+	case *Instance:
+		if obj.frozen {
+			return
+		}
+		obj.frozen = true
+		for _, v := range obj.fields {
+			freeze(v)
+		}
+	}
+}
+
+// bIsFrozen reports whether a list or instance has been frozen. Other values are always immutable.
+func bIsFrozen(interp *Interpreter, args []object.Object) (object.Object, error) {
+	frozen := true
+	switch obj := args[0].(type) {
+	case *List:
+		frozen = obj.frozen
+	case *Instance:
+		frozen = obj.frozen
+	}
+
+	if frozen {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bContains reports whether a list holds an element equal to value, using the equality of == so that instances
+// are compared with their equals method. For a string it reports whether value is a substring.
+func bContains(interp *Interpreter, args []object.Object) (object.Object, error) {
+	switch obj := args[0]; obj.Type() {
+	case object.String:
+		sub, ok := args[1].(*String)
+		if !ok {
+			return NullOb, BIError("'contains' value must be a STRING when searching a string.")
+		}
+		if strings.Contains(obj.(*String).Value, sub.Value) {
+			return True, nil
+		}
+		return False, nil
+	case object.List:
+		for _, el := range obj.(*List).Elements {
+			eq, err := interp.equal(nil, el, args[1])
+			if err != nil {
+				return NullOb, err
+			}
+			if eq {
+				return True, nil
+			}
+		}
+		return False, nil
+	}
+
+	return NullOb, BIError("'contains' argument must be of a type STRING or LIST.")
+}
+
+// bSlice returns the characters of a string, or elements of a list, from start up to but not including end. end
+// defaults to the length of the value. Negative positions count back from the end and positions beyond either end
+// are clamped.
+func bSlice(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return NullOb, BIError("'slice' expects a value, a start and an optional end.")
+	}
+
+	var length int
+	var runes []rune
+	switch obj := args[0]; obj.Type() {
+	case object.String:
+		runes = []rune(obj.(*String).Value)
+		length = len(runes)
+	case object.List:
+		length = len(obj.(*List).Elements)
+	default:
+		return NullOb, BIError("'slice' argument must be of a type STRING or LIST.")
+	}
+
+	bounds := []int{0, length}
+	for i, arg := range args[1:] {
+		n, ok := arg.(*Number)
+		if !ok || !n.IsInt {
+			return NullOb, BIError("'slice' positions must be integers.")
+		}
+
+		pos := n.Int
+		if n.Big != nil {
+			pos = length
+			if n.Big.Sign() < 0 {
+				pos = 0
+			}
+		} else if pos < 0 {
+			pos += length
+		}
+		if pos < 0 {
+			pos = 0
+		} else if pos > length {
+			pos = length
+		}
+		bounds[i] = pos
+	}
+
+	start, end := bounds[0], bounds[1]
+	if end < start {
+		end = start
+	}
+
+	if args[0].Type() == object.String {
+		return &String{Value: string(runes[start:end])}, nil
+	}
+
+	elements := make([]object.Object, end-start)
+	copy(elements, args[0].(*List).Elements[start:end])
+	return &List{Elements: elements}, nil
+}
+
+// bChars returns a list holding each character of a string as its own string.
+func bChars(interp *Interpreter, args []object.Object) (object.Object, error) {
+	s, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'chars' argument must be of a type STRING.")
+	}
+
+	var chars []object.Object
+	for _, r := range s.Value {
+		chars = append(chars, &String{Value: string(r)})
+	}
+	return &List{Elements: chars}, nil
+}
+
+// bBytes returns a list of the UTF-8 encoded bytes of a string as numbers.
+func bBytes(interp *Interpreter, args []object.Object) (object.Object, error) {
+	s, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'bytes' argument must be of a type STRING.")
+	}
+
+	bytes := make([]object.Object, len(s.Value))
+	for i := 0; i < len(s.Value); i++ {
+		bytes[i] = &Number{IsInt: true, Int: int(s.Value[i])}
+	}
+	return &List{Elements: bytes}, nil
+}
+
 // TODO Remove this when I get something better
 func bDebugPrint(inter *Interpreter, args []object.Object) (object.Object, error) {
 	if len(args) < 1 {
-		fmt.Println("")
+		fmt.Fprintln(inter.stdout, "")
 		return NullOb, nil
 	}
 
-	fmt.Printf("%s", args[0].String())
-	for i := 1; i < len(args); i++ {
-		fmt.Printf(" %s", args[i].String())
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		s, err := inter.stringify(nil, arg)
+		if err != nil {
+			return NullOb, err
+		}
+		strs[i] = s
 	}
-	fmt.Println("")
+
+	fmt.Fprintln(inter.stdout, strings.Join(strs, " "))
 	return NullOb, nil
 }
@@ -1,13 +1,48 @@
 package interpreter
 
-import "github.com/butlermatt/glpc/object"
+import (
+	"fmt"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
 
 type Callable interface {
-	// Arity is the number of expected arguments
+	// Arity is the number of expected arguments, or the most accepted when some have default values. It is -1 if
+	// any number of arguments are accepted.
 	Arity() int
 	Call(interpreter *Interpreter, args []object.Object) (object.Object, error)
 }
 
+// arityRange returns the fewest and the most arguments fn accepts. The most is -1 when there is no limit.
+func arityRange(fn Callable) (int, int) {
+	switch f := fn.(type) {
+	case *Function:
+		return f.minArity(), f.Arity()
+	case *Class:
+		if init := f.methods["init"]; init != nil {
+			return init.minArity(), init.Arity()
+		}
+	}
+
+	if fn.Arity() == -1 {
+		return 0, -1
+	}
+	return fn.Arity(), fn.Arity()
+}
+
+// arityError describes a call to fn with the wrong number of arguments.
+func arityError(fn Callable, got int) string {
+	min, max := arityRange(fn)
+	switch {
+	case max == -1:
+		return fmt.Sprintf("Expected at least %d arguments but got %d", min, got)
+	case min != max:
+		return fmt.Sprintf("Expected %d to %d arguments but got %d", min, max, got)
+	}
+	return fmt.Sprintf("Expected %d arguments but got %d", max, got)
+}
+
 type Function struct {
 	declaration *object.FunctionStmt
 	closure     *object.Environment
@@ -20,21 +55,94 @@ func NewFunction(declaration *object.FunctionStmt, env *object.Environment, isIn
 
 func (f *Function) Type() object.Type { return object.Function }
 func (f *Function) String() string    { return "<fn " + f.declaration.Name.Lexeme + ">" }
-func (f *Function) Arity() int        { return len(f.declaration.Parameters) }
+
+func (f *Function) Arity() int {
+	if f.declaration.Variadic {
+		return -1
+	}
+	return len(f.declaration.Parameters)
+}
+
+// minArity returns the number of parameters which have no default value and are not a rest parameter.
+func (f *Function) minArity() int {
+	n := 0
+	for i := range f.fixedParams() {
+		if f.declaration.Defaults == nil || f.declaration.Defaults[i] == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// fixedParams returns the parameters of the function other than its rest parameter.
+func (f *Function) fixedParams() []*lexer.Token {
+	params := f.declaration.Parameters
+	if f.declaration.Variadic {
+		return params[:len(params)-1]
+	}
+	return params
+}
+
+// Call calls the function with args. Parameters without an argument, or whose argument is nil because named
+// arguments skipped over it, take their default values. Arguments after the last fixed parameter are collected into
+// a list for the rest parameter.
 func (f *Function) Call(interpreter *Interpreter, args []object.Object) (object.Object, error) {
-	if len(args) != f.Arity() {
+	if max := f.Arity(); max != -1 && len(args) > max {
 		return nil, object.NewRuntimeError(f.declaration.Name, "Incorrect number of arguments passed.")
 	}
 
 	env := object.NewEnclosedEnvironment(f.closure)
-	for i, p := range f.declaration.Parameters {
-		env.Define(p, args[i])
+	params := f.fixedParams()
+	for i, p := range params {
+		var value object.Object
+		switch {
+		case i < len(args) && args[i] != nil:
+			value = args[i]
+		case f.declaration.Defaults != nil && f.declaration.Defaults[i] != nil:
+			v, err := interpreter.evaluateIn(f.declaration.Defaults[i], env)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		default:
+			return nil, BIError("Missing argument for parameter " + p.Lexeme + ".")
+		}
+
+		if err := interpreter.checkType(f.paramType(i), value, f.closure, "Parameter "+p.Lexeme); err != nil {
+			return nil, err
+		}
+		env.Define(p, value)
 	}
 
+	if f.declaration.Variadic {
+		rest := &List{}
+		if len(args) > len(params) {
+			rest.Elements = append(rest.Elements, args[len(params):]...)
+		}
+
+		// The type of a rest parameter is the type of each argument collected into it.
+		name := f.declaration.Parameters[len(params)]
+		for _, value := range rest.Elements {
+			if err := interpreter.checkType(f.paramType(len(params)), value, f.closure, "Parameter "+name.Lexeme); err != nil {
+				return nil, err
+			}
+		}
+		env.Define(name, rest)
+	}
+
+	// The body of a generator does not run until the generator is first resumed.
+	if f.declaration.Generator {
+		return newGenerator(interpreter, f, env), nil
+	}
+	return f.run(interpreter, env)
+}
+
+// run executes the body of the function in env, which holds its parameters, and returns the value it returns.
+func (f *Function) run(interpreter *Interpreter, env *object.Environment) (object.Object, error) {
 	err := interpreter.executeBlock(f.declaration.Body, env)
 	if err != nil {
 		if e, ok := err.(*ReturnError); ok {
-			return e.Value, nil
+			return f.checkReturn(interpreter, e.Token, e.Value)
 		}
 		return nil, err
 	}
@@ -43,11 +151,78 @@ func (f *Function) Call(interpreter *Interpreter, args []object.Object) (object.
 		return f.closure.GetString("this"), nil
 	}
 
-	return NullOb, nil
+	return f.checkReturn(interpreter, f.declaration.Name, NullOb)
+}
+
+// paramType returns the type annotation of parameter i, or nil if it has none.
+func (f *Function) paramType(i int) *object.TypeAnnotation {
+	if f.declaration.ParamTypes == nil {
+		return nil
+	}
+	return f.declaration.ParamTypes[i]
+}
+
+// namedArgs returns the arguments of a call with named arguments placed at the position of their parameter. The
+// parameters which are not passed are left nil, so that they take their default values.
+func namedArgs(callee Callable, expr *object.CallExpr, args []object.Object) ([]object.Object, error) {
+	fn, ok := callee.(*Function)
+	if klass, isClass := callee.(*Class); isClass {
+		fn, ok = klass.methods["init"], klass.methods["init"] != nil
+	}
+	if !ok {
+		return nil, object.NewRuntimeError(expr.Paren, "Only functions declared in a script accept named arguments.")
+	}
+
+	positional := 0
+	for positional < len(expr.Names) && expr.Names[positional] == nil {
+		positional++
+	}
+
+	params := fn.fixedParams()
+	if positional > len(params) && !fn.declaration.Variadic {
+		return nil, object.NewRuntimeError(expr.Paren, arityError(callee, len(args)))
+	}
+	placed := make([]object.Object, len(params))
+	if positional > len(params) {
+		placed = make([]object.Object, positional)
+	}
+	copy(placed, args[:positional])
+
+	for i, name := range expr.Names[positional:] {
+		index := -1
+		for j, p := range params {
+			if p.Lexeme == name.Lexeme {
+				index = j
+			}
+		}
+
+		switch {
+		case index == -1 && fn.declaration.Variadic && fn.declaration.Parameters[len(params)].Lexeme == name.Lexeme:
+			return nil, object.NewRuntimeError(name, "Rest parameter cannot be passed by name.")
+		case index == -1:
+			return nil, object.NewRuntimeError(name, "No parameter named "+name.Lexeme+".")
+		case placed[index] != nil:
+			return nil, object.NewRuntimeError(name, "Argument "+name.Lexeme+" is passed more than once.")
+		}
+		placed[index] = args[positional+i]
+	}
+
+	return placed, nil
+}
+
+// checkReturn checks value against the declared return type of the function, reporting a mismatch at tok.
+func (f *Function) checkReturn(interpreter *Interpreter, tok *lexer.Token, value object.Object) (object.Object, error) {
+	what := "Return value of " + f.declaration.Name.Lexeme
+	if err := interpreter.checkType(f.declaration.ReturnType, value, f.closure, what); err != nil {
+		return nil, callError(tok, err)
+	}
+	return value, nil
 }
 
-func (f *Function) Bind(inst *Instance) *Function {
+// Bind returns a copy of the function with this defined as this, which is an instance for methods and the class
+// itself for static methods.
+func (f *Function) Bind(this object.Object) *Function {
 	env := object.NewEnclosedEnvironment(f.closure)
-	env.DefineString("this", inst)
+	env.DefineString("this", this)
 	return NewFunction(f.declaration, env, f.isInit)
 }
@@ -9,6 +9,16 @@ type Class struct {
 	Name       string
 	superclass *Class
 	methods    map[string]*Function
+	getters    map[string]*Function
+	setters    map[string]*Function
+	statics    map[string]object.Object // Static fields.
+
+	staticMethods map[string]*Function
+	staticFields  []*object.VarStmt // Static field declarations, kept for their types.
+
+	fields  []*object.VarStmt   // Instance fields, initialized for each new instance.
+	closure *object.Environment // Environment the instance field initializers are evaluated in.
+	trait   *Trait              // The trait this class was created from by mixin, if any.
 }
 
 func (c *Class) Type() object.Type { return object.Class }
@@ -24,6 +34,10 @@ func (c *Class) Arity() int {
 
 func (c *Class) Call(interp *Interpreter, args []object.Object) (object.Object, error) {
 	inst := &Instance{klass: c, fields: make(map[string]object.Object)}
+	if err := c.initFields(interp, inst); err != nil {
+		return nil, err
+	}
+
 	init := c.methods["init"]
 	if init != nil {
 		_, err := init.Bind(inst).Call(interp, args)
@@ -35,6 +49,39 @@ func (c *Class) Call(interp *Interpreter, args []object.Object) (object.Object,
 	return inst, nil
 }
 
+// initFields sets the fields declared in the class body of c, and of its superclasses first, to their default
+// values. Each initializer is evaluated with this bound to the new instance.
+func (c *Class) initFields(interp *Interpreter, inst *Instance) error {
+	if c.superclass != nil {
+		if err := c.superclass.initFields(interp, inst); err != nil {
+			return err
+		}
+	}
+
+	if len(c.fields) == 0 {
+		return nil
+	}
+
+	env := object.NewEnclosedEnvironment(c.closure)
+	env.DefineString("this", inst)
+	for _, field := range c.fields {
+		var value object.Object = NullOb
+		if field.Value != nil {
+			v, err := interp.evaluateIn(field.Value, env)
+			if err != nil {
+				return err
+			}
+			if err := interp.checkType(field.Type, v, env, "Field "+field.Name.Lexeme); err != nil {
+				return callError(field.Name, err)
+			}
+			value = v
+		}
+		inst.fields[field.Name.Lexeme] = value
+	}
+
+	return nil
+}
+
 func (c *Class) findMethod(inst *Instance, name string) *Function {
 	method := c.methods[name]
 	if method != nil {
@@ -48,15 +95,85 @@ func (c *Class) findMethod(inst *Instance, name string) *Function {
 	return nil
 }
 
+// checkField checks value against the type of the field name, as declared by the closest class declaring the field.
+func (c *Class) checkField(interp *Interpreter, name string, value object.Object) error {
+	for k := c; k != nil; k = k.superclass {
+		for _, field := range k.fields {
+			if field.Name.Lexeme == name {
+				return interp.checkType(field.Type, value, k.closure, "Field "+name)
+			}
+		}
+	}
+	return nil
+}
+
+// findAccessor returns the getter (or setter) for the property name declared by c or its closest superclass
+// declaring one.
+func (c *Class) findAccessor(name string, setter bool) *Function {
+	for k := c; k != nil; k = k.superclass {
+		accessors := k.getters
+		if setter {
+			accessors = k.setters
+		}
+		if fn := accessors[name]; fn != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// GetStatic returns the static field or method name of c or its superclasses. Methods are bound to c, so this is
+// the class they were called on even when they are inherited.
+func (c *Class) GetStatic(name *lexer.Token) (object.Object, error) {
+	return c.getStatic(c, name)
+}
+
+func (c *Class) getStatic(this *Class, name *lexer.Token) (object.Object, error) {
+	for k := c; k != nil; k = k.superclass {
+		if v, ok := k.statics[name.Lexeme]; ok {
+			return v, nil
+		}
+		if m := k.staticMethods[name.Lexeme]; m != nil {
+			return m.Bind(this), nil
+		}
+	}
+
+	return nil, object.NewRuntimeError(name, "Undefined static property on "+c.Name+".")
+}
+
+// SetStatic assigns a static field, which must hold a value of the type it was declared with. A field inherited from
+// a superclass is shared with it, otherwise the field is created on c.
+func (c *Class) SetStatic(interp *Interpreter, name *lexer.Token, value object.Object) error {
+	for k := c; k != nil; k = k.superclass {
+		if _, ok := k.statics[name.Lexeme]; ok {
+			if err := interp.checkType(fieldType(k.staticFields, name.Lexeme), value, k.closure, "Field "+name.Lexeme); err != nil {
+				return err
+			}
+			k.statics[name.Lexeme] = value
+			return nil
+		}
+	}
+
+	c.statics[name.Lexeme] = value
+	return nil
+}
+
 type Instance struct {
 	klass  *Class
 	fields map[string]object.Object
+	frozen bool // Set by freeze, after which no property can be assigned.
 }
 
 func (in *Instance) Type() object.Type { return object.Instance }
 func (in *Instance) String() string    { return in.klass.Name + " instance" }
 
-func (in *Instance) Get(name *lexer.Token) (object.Object, error) {
+// Get returns the property name of the instance. A getter declared for the property is called in preference to a
+// field of the same name, and fields in preference to methods.
+func (in *Instance) Get(interp *Interpreter, name *lexer.Token) (object.Object, error) {
+	if getter := in.klass.findAccessor(name.Lexeme, false); getter != nil {
+		return getter.Bind(in).Call(interp, nil)
+	}
+
 	if v, ok := in.fields[name.Lexeme]; ok {
 		return v, nil
 	}
@@ -68,6 +185,26 @@ func (in *Instance) Get(name *lexer.Token) (object.Object, error) {
 	return nil, object.NewRuntimeError(name, "Undefined property.")
 }
 
-func (in *Instance) Set(name *lexer.Token, value object.Object) {
+// Set assigns the property name of the instance, calling its setter if one is declared. A property with a getter
+// but no setter cannot be assigned, and nothing can be assigned once the instance is frozen.
+func (in *Instance) Set(interp *Interpreter, name *lexer.Token, value object.Object) error {
+	if in.frozen {
+		return BIError("Cannot modify a frozen instance.")
+	}
+
+	if setter := in.klass.findAccessor(name.Lexeme, true); setter != nil {
+		_, err := setter.Bind(in).Call(interp, []object.Object{value})
+		return err
+	}
+
+	if in.klass.findAccessor(name.Lexeme, false) != nil {
+		return object.NewRuntimeError(name, "Cannot assign to read-only property.")
+	}
+
+	if err := in.klass.checkField(interp, name.Lexeme, value); err != nil {
+		return err
+	}
+
 	in.fields[name.Lexeme] = value
+	return nil
 }
@@ -0,0 +1,222 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// Clock is the game clock of an interpreter, shared by its forks and the interpreters of the files it imports. It
+// counts ticks rather than measuring time, and only moves when the host advances it, so timers always run in the
+// same order no matter how long each tick takes. The MUD server advances it once per game tick; tests advance it
+// as far as they need to.
+type Clock struct {
+	now       int
+	nextID    int
+	timers    []*timer // Ordered by the tick they are due, then by id.
+	running   *timer   // The timer being called, which may cancel itself.
+	advancing bool
+}
+
+// timer is a function added to a Clock by after or every. A timer which repeats is due again every ticks after it
+// runs.
+type timer struct {
+	id        int
+	due       int
+	every     int
+	fn        Callable
+	cancelled bool
+}
+
+// schedule adds fn to be called once the clock reaches due, and returns its id for cancel.
+func (c *Clock) schedule(fn Callable, due, every int) int {
+	c.nextID++
+	c.insert(&timer{id: c.nextID, due: due, every: every, fn: fn})
+	return c.nextID
+}
+
+func (c *Clock) insert(t *timer) {
+	i := sort.Search(len(c.timers), func(i int) bool {
+		o := c.timers[i]
+		return o.due > t.due || (o.due == t.due && o.id > t.id)
+	})
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = t
+}
+
+// cancel stops the timer with id from running again, and reports whether it was still scheduled.
+func (c *Clock) cancel(id int) bool {
+	if c.running != nil && c.running.id == id && c.running.every > 0 && !c.running.cancelled {
+		c.running.cancelled = true
+		return true
+	}
+	for i, t := range c.timers {
+		if t.id == id {
+			t.cancelled = true
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// due removes and returns the first timer due by the current tick, or nil if there is none.
+func (c *Clock) due() *timer {
+	if len(c.timers) == 0 || c.timers[0].due > c.now {
+		return nil
+	}
+	t := c.timers[0]
+	c.timers = c.timers[1:]
+	return t
+}
+
+// Now returns the current tick of the interpreter's clock, which starts at 0.
+func (inter *Interpreter) Now() int {
+	inter.tasks.mu.Lock()
+	defer inter.tasks.mu.Unlock()
+
+	return inter.clock.now
+}
+
+// Advance moves the clock on by ticks, which must be positive, and calls the timers due at each tick on the way in
+// the order they were added. A timer which fails is not called again, and the errors of all which failed are
+// returned. Advancing the clock while a timer waits on a channel fails.
+func (inter *Interpreter) Advance(ticks int) []error {
+	inter.tasks.enter()
+	defer inter.tasks.leave()
+
+	errs, err := inter.advance(ticks)
+	if err != nil {
+		return []error{err}
+	}
+	return errs
+}
+
+// advance moves the clock on with the lock already held. It fails if ticks is not positive, or if a timer, or
+// anything it calls, is already advancing the clock.
+func (inter *Interpreter) advance(ticks int) ([]error, error) {
+	c := inter.clock
+	if ticks < 1 {
+		return nil, BIError("Cannot advance the clock by less than one tick.")
+	}
+	if c.advancing {
+		return nil, BIError("Cannot advance the clock from a timer.")
+	}
+	if ticks > math.MaxInt-c.now {
+		return nil, BIError("Cannot advance the clock past its last tick.")
+	}
+	c.advancing = true
+	defer func() { c.advancing, c.running = false, nil }()
+
+	end := c.now + ticks
+	var errs []error
+	// The clock moves straight to the next tick a timer is due, rather than through each tick in between.
+	for len(c.timers) > 0 && c.timers[0].due <= end {
+		if c.timers[0].due > c.now {
+			c.now = c.timers[0].due
+		}
+		for t := c.due(); t != nil; t = c.due() {
+			c.running = t
+			_, err := t.fn.Call(inter, nil)
+			c.running = nil
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			// A timer due again after the last tick the clock can reach would never run.
+			if t.every > 0 && !t.cancelled && t.due <= math.MaxInt-t.every {
+				t.due += t.every
+				c.insert(t)
+			}
+		}
+	}
+	c.now = end
+	return errs, nil
+}
+
+// ticksArg returns args[0] of the builtin name, which must be a positive integer no more ticks from now than the
+// clock can reach.
+func ticksArg(interp *Interpreter, name string, args []object.Object) (int, error) {
+	n, ok := args[0].(*Number)
+	if !ok || !n.IsInt || (n.Big == nil && n.Int < 1) || (n.Big != nil && n.Big.Sign() < 0) {
+		return 0, BIError("'" + name + "' ticks must be a positive integer.")
+	}
+	if n.Big != nil || n.Int > math.MaxInt-interp.clock.now {
+		return 0, BIError("'" + name + "' ticks must not pass the last tick of the clock.")
+	}
+	return n.Int, nil
+}
+
+// timerArgs returns the ticks and function passed to the builtin name.
+func timerArgs(interp *Interpreter, name string, args []object.Object) (int, Callable, error) {
+	ticks, err := ticksArg(interp, name, args)
+	if err != nil {
+		return 0, nil, err
+	}
+	fn, ok := args[1].(Callable)
+	if !ok {
+		return 0, nil, BIError("'" + name + "' argument must be a function.")
+	}
+	if min, _ := arityRange(fn); min > 0 {
+		return 0, nil, BIError("'" + name + "' function must not require any arguments.")
+	}
+	return ticks, fn, nil
+}
+
+// bAfter calls a function once, the given number of ticks from now, and returns a timer id for cancel.
+func bAfter(interp *Interpreter, args []object.Object) (object.Object, error) {
+	ticks, fn, err := timerArgs(interp, "after", args)
+	if err != nil {
+		return NullOb, err
+	}
+	return &Number{IsInt: true, Int: interp.clock.schedule(fn, interp.clock.now+ticks, 0)}, nil
+}
+
+// bEvery calls a function every given number of ticks, starting that many ticks from now, until it is cancelled or
+// fails. It returns a timer id for cancel.
+func bEvery(interp *Interpreter, args []object.Object) (object.Object, error) {
+	ticks, fn, err := timerArgs(interp, "every", args)
+	if err != nil {
+		return NullOb, err
+	}
+	return &Number{IsInt: true, Int: interp.clock.schedule(fn, interp.clock.now+ticks, ticks)}, nil
+}
+
+// bCancel stops a timer added by after or every, and reports whether it was still scheduled.
+func bCancel(interp *Interpreter, args []object.Object) (object.Object, error) {
+	n, ok := args[0].(*Number)
+	if !ok || !n.IsInt || n.Big != nil {
+		return NullOb, BIError("'cancel' argument must be a timer id.")
+	}
+
+	if interp.clock.cancel(n.Int) {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bNow returns the current tick of the game clock.
+func bNow(interp *Interpreter, args []object.Object) (object.Object, error) {
+	return &Number{IsInt: true, Int: interp.clock.now}, nil
+}
+
+// bAdvance moves the game clock on by a number of ticks, calling the timers due on the way, so that tests can run
+// scheduled behavior without waiting for it. Timers which fail are reported without stopping the others.
+func bAdvance(interp *Interpreter, args []object.Object) (object.Object, error) {
+	ticks, err := ticksArg(interp, "advance", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	errs, err := interp.advance(ticks)
+	if err != nil {
+		return NullOb, err
+	}
+	for _, err := range errs {
+		fmt.Fprintf(interp.stderr, "[Timer error] %v\n", err)
+	}
+	return NullOb, nil
+}
@@ -0,0 +1,122 @@
+package interpreter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+)
+
+func TestHostClock(t *testing.T) {
+	input := `
+var log = "";
+
+fn respawn() {
+  log = "${log}[respawn ${now()}]";
+}
+
+fn regen() {
+  log = "${log}[regen ${now()}]";
+}
+
+fn broken() {
+  log = "${log}[broken ${now()}]";
+  return log.name;
+}
+
+fn main() {
+  after(3, respawn);
+  every(2, regen);
+  every(1, broken);
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "clock.glpc")), "clock.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run main: %v", err)
+	}
+
+	tests := []struct {
+		ticks  int
+		now    int
+		errors int
+		logged string
+	}{
+		{1, 1, 1, "[broken 1]"},
+		{1, 2, 0, "[broken 1][regen 2]"},
+		{3, 5, 0, "[broken 1][regen 2][respawn 3][regen 4]"},
+	}
+
+	for i, tt := range tests {
+		errs := interp.Advance(tt.ticks)
+		if len(errs) != tt.errors {
+			t.Errorf("test %d: wrong number of errors. expected=%d, got=%v", i+1, tt.errors, errs)
+		}
+		if now := interp.Now(); now != tt.now {
+			t.Errorf("test %d: wrong tick. expected=%d, got=%d", i+1, tt.now, now)
+		}
+		if logged := env.GetString("log").String(); logged != tt.logged {
+			t.Errorf("test %d: wrong timers called. expected=%q, got=%q", i+1, tt.logged, logged)
+		}
+	}
+}
+
+func TestClockLastTick(t *testing.T) {
+	input := `
+var count = 0;
+
+fn tick() {
+  count++;
+}
+
+fn main() {
+  every(period, tick);
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "last.glpc")), "last.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+	env.DefineString("period", &Number{IsInt: true, Int: math.MaxInt/3 + 1})
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run main: %v", err)
+	}
+
+	// The timer runs twice; the third time would be past the last tick, so it is dropped rather than overflowing.
+	if errs := interp.Advance(math.MaxInt); len(errs) != 0 {
+		t.Fatalf("unexpected timer errors: %v", errs)
+	}
+	if now := interp.Now(); now != math.MaxInt {
+		t.Errorf("wrong tick. expected=%d, got=%d", math.MaxInt, now)
+	}
+	if count := env.GetString("count").String(); count != "2" {
+		t.Errorf("wrong number of ticks. expected=%q, got=%q", "2", count)
+	}
+
+	if errs := interp.Advance(1); len(errs) != 1 {
+		t.Errorf("expected an error advancing past the last tick. got=%v", errs)
+	}
+}
+
+func TestClockBackwards(t *testing.T) {
+	interp := New()
+	if errs := interp.Advance(5); len(errs) != 0 {
+		t.Fatalf("unexpected timer errors: %v", errs)
+	}
+
+	for _, ticks := range []int{0, -3} {
+		if errs := interp.Advance(ticks); len(errs) != 1 {
+			t.Errorf("expected an error advancing by %d ticks. got=%v", ticks, errs)
+		}
+		if now := interp.Now(); now != 5 {
+			t.Errorf("wrong tick after advancing by %d. expected=%d, got=%d", ticks, 5, now)
+		}
+	}
+}
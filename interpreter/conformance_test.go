@@ -0,0 +1,161 @@
+package interpreter
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+)
+
+// The conformance suite runs every program in testdata/conformance and compares what it writes to stdout and
+// stderr, and the exit status it would have, against a sidecar .golden file of the same name. Golden files hold
+// sections introduced by "-- stdout --", "-- stderr --" and "-- status --" lines. Run the tests with -update to
+// regenerate them after an intended change in behaviour.
+
+var update = flag.Bool("update", false, "rewrite the conformance golden files with the current results")
+
+const conformanceDir = "testdata/conformance"
+
+type programResult struct {
+	stdout string
+	stderr string
+	status int
+}
+
+func (pr programResult) golden() []byte {
+	var b bytes.Buffer
+	b.WriteString("-- stdout --\n")
+	b.WriteString(pr.stdout)
+	b.WriteString("-- stderr --\n")
+	b.WriteString(pr.stderr)
+	b.WriteString("-- status --\n")
+	b.WriteString(strconv.Itoa(pr.status) + "\n")
+	return b.Bytes()
+}
+
+func parseGolden(data []byte) (programResult, error) {
+	var pr programResult
+	sections := make(map[string]*bytes.Buffer)
+	var cur *bytes.Buffer
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") {
+			cur = &bytes.Buffer{}
+			sections[strings.TrimSuffix(strings.TrimPrefix(trimmed, "-- "), " --")] = cur
+			continue
+		}
+		if cur == nil {
+			if trimmed != "" {
+				return pr, fmt.Errorf("content before first section: %q", line)
+			}
+			continue
+		}
+		cur.WriteString(line)
+	}
+
+	if s, ok := sections["stdout"]; ok {
+		pr.stdout = s.String()
+	}
+	if s, ok := sections["stderr"]; ok {
+		pr.stderr = s.String()
+	}
+	if s, ok := sections["status"]; ok {
+		status, err := strconv.Atoi(strings.TrimSpace(s.String()))
+		if err != nil {
+			return pr, fmt.Errorf("invalid status: %v", err)
+		}
+		pr.status = status
+	}
+
+	return pr, nil
+}
+
+// runProgram interprets the program at path and calls its main function, the same way the glpc command does.
+func runProgram(path string) (programResult, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return programResult{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	interp := New()
+	interp.SetOutput(&stdout, &stderr)
+	interp.SetSeed(1)
+
+	env, err := interp.Interpret(parser.New(lexer.New(input, path)), path)
+	if err == nil {
+		err = interp.RunMain(env)
+	}
+	for _, taskErr := range interp.Wait() {
+		if err == nil {
+			err = taskErr
+			continue
+		}
+		fmt.Fprintf(&stderr, "%v\n", taskErr)
+	}
+
+	pr := programResult{}
+	if err != nil {
+		fmt.Fprintf(&stderr, "%v\n", err)
+		pr.status = 1
+	}
+	pr.stdout = stdout.String()
+	pr.stderr = stderr.String()
+	return pr, nil
+}
+
+func TestConformance(t *testing.T) {
+	programs, err := filepath.Glob(filepath.Join(conformanceDir, "*.glpc"))
+	if err != nil {
+		t.Fatalf("unable to list conformance programs: %v", err)
+	}
+	if len(programs) == 0 {
+		t.Fatalf("no conformance programs found in %s", conformanceDir)
+	}
+
+	for _, program := range programs {
+		name := strings.TrimSuffix(filepath.Base(program), ".glpc")
+		goldenPath := strings.TrimSuffix(program, ".glpc") + ".golden"
+
+		t.Run(name, func(t *testing.T) {
+			got, err := runProgram(program)
+			if err != nil {
+				t.Fatalf("unable to run program: %v", err)
+			}
+
+			if *update {
+				if err := ioutil.WriteFile(goldenPath, got.golden(), 0644); err != nil {
+					t.Fatalf("unable to write golden file: %v", err)
+				}
+				return
+			}
+
+			data, err := ioutil.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("unable to read golden file: %v", err)
+			}
+			expect, err := parseGolden(data)
+			if err != nil {
+				t.Fatalf("malformed golden file %s: %v", goldenPath, err)
+			}
+
+			if got.stdout != expect.stdout {
+				t.Errorf("wrong stdout.\nexpected:\n%s\ngot:\n%s", expect.stdout, got.stdout)
+			}
+			if got.stderr != expect.stderr {
+				t.Errorf("wrong stderr.\nexpected:\n%s\ngot:\n%s", expect.stderr, got.stderr)
+			}
+			if got.status != expect.status {
+				t.Errorf("wrong exit status. expected=%d, got=%d", expect.status, got.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// EventBus holds the handlers of named events, which scripts add with the on builtin and the host with On. It is
+// shared by an interpreter, its forks and the interpreters of the files it imports.
+type EventBus struct {
+	mu       sync.Mutex
+	handlers map[string][]*handler
+	nextID   int
+}
+
+// handler is a callable added to an EventBus. Handlers with a higher priority are called first, and handlers with
+// the same priority in the order they were added.
+type handler struct {
+	id       int
+	fn       Callable
+	priority int
+	removed  bool
+}
+
+func newEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]*handler)}
+}
+
+// On adds fn as a handler of event and returns an id which Off removes it with.
+func (b *EventBus) On(event string, fn Callable, priority int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	h := &handler{id: b.nextID, fn: fn, priority: priority}
+
+	// The handlers are copied, as they may be in the middle of being called.
+	hs := append(append([]*handler(nil), b.handlers[event]...), h)
+	sort.SliceStable(hs, func(i, j int) bool { return hs[i].priority > hs[j].priority })
+	b.handlers[event] = hs
+	return h.id
+}
+
+// Off removes the handler with the id returned by On, and reports whether there was one. A handler removed while an
+// event is being emitted is not called for it, if it has not been called already.
+func (b *EventBus) Off(id int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for event, hs := range b.handlers {
+		for i, h := range hs {
+			if h.id != id {
+				continue
+			}
+			h.removed = true
+			b.handlers[event] = append(hs[:i:i], hs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// handlersOf returns the handlers of event in the order they are called. Handlers added while the event is being
+// emitted are not called for it.
+func (b *EventBus) handlersOf(event string) []*handler {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.handlers[event]
+}
+
+func (b *EventBus) isRemoved(h *handler) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return h.removed
+}
+
+// Events returns the event bus of the interpreter, for the host to add handlers to.
+func (inter *Interpreter) Events() *EventBus {
+	return inter.events
+}
+
+// Emit calls each handler of event with args, as the emit builtin does, and reports whether the event was not
+// cancelled. The errors of any handlers which failed are returned rather than reported, and did not stop the other
+// handlers being called.
+func (inter *Interpreter) Emit(event string, args ...object.Object) (bool, []error) {
+	inter.tasks.enter()
+	defer inter.tasks.leave()
+
+	return inter.emit(event, args)
+}
+
+// emit calls the handlers of event in order until one returns false, which cancels the event. A handler is passed
+// no more of args than it accepts, and fails if it needs more than there are.
+func (inter *Interpreter) emit(event string, args []object.Object) (bool, []error) {
+	var errs []error
+	for _, h := range inter.events.handlersOf(event) {
+		if inter.events.isRemoved(h) {
+			continue
+		}
+
+		handlerArgs := args
+		min, max := arityRange(h.fn)
+		if len(args) < min {
+			errs = append(errs, BIError(arityError(h.fn, len(args))))
+			continue
+		}
+		if max != -1 && len(args) > max {
+			handlerArgs = args[:max]
+		}
+
+		value, err := h.fn.Call(inter, handlerArgs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if value == False {
+			return false, errs
+		}
+	}
+	return true, errs
+}
+
+// bOn adds a function as a handler of the named event, with an optional priority, and returns its id for off.
+// Handlers with a higher priority are called first.
+func bOn(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return NullOb, BIError("'on' expects an event name, a handler and an optional priority.")
+	}
+
+	event, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'on' event name must be of a type STRING.")
+	}
+	fn, ok := args[1].(Callable)
+	if !ok {
+		return NullOb, BIError("'on' handler must be a function.")
+	}
+
+	priority := 0
+	if len(args) == 3 {
+		n, ok := args[2].(*Number)
+		if !ok || !n.IsInt || n.Big != nil {
+			return NullOb, BIError("'on' priority must be an integer.")
+		}
+		priority = n.Int
+	}
+
+	return &Number{IsInt: true, Int: interp.events.On(event.Value, fn, priority)}, nil
+}
+
+// bOff removes the handler with an id returned by on, and reports whether there was one.
+func bOff(interp *Interpreter, args []object.Object) (object.Object, error) {
+	n, ok := args[0].(*Number)
+	if !ok || !n.IsInt || n.Big != nil {
+		return NullOb, BIError("'off' argument must be a handler id.")
+	}
+
+	if interp.events.Off(n.Int) {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bEmit calls the handlers of the named event with the rest of the arguments, and returns false if a handler
+// cancelled it by returning false. A handler which fails is reported without stopping the others.
+func bEmit(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 {
+		return NullOb, BIError("'emit' expects an event name and its arguments.")
+	}
+
+	event, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'emit' event name must be of a type STRING.")
+	}
+
+	done, errs := interp.emit(event.Value, args[1:])
+	for _, err := range errs {
+		fmt.Fprintf(interp.stderr, "[Event error] %s: %v\n", event.Value, err)
+	}
+
+	if done {
+		return True, nil
+	}
+	return False, nil
+}
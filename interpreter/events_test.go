@@ -0,0 +1,93 @@
+package interpreter
+
+import (
+	"testing"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+	"github.com/butlermatt/glpc/parser"
+)
+
+// recorder is a handler written in Go, which records the arguments it is called with.
+type recorder struct {
+	calls [][]object.Object
+}
+
+func (r *recorder) Arity() int { return -1 }
+func (r *recorder) Call(interpreter *Interpreter, args []object.Object) (object.Object, error) {
+	r.calls = append(r.calls, args)
+	return NullOb, nil
+}
+
+func TestHostEvents(t *testing.T) {
+	input := `
+var said = "";
+
+fn listen(who, text) {
+  said = said + "[" + who + ": " + text + "]";
+}
+
+fn filter(who, text) {
+  if (text == "spam") {
+    return false;
+  }
+}
+
+fn broken(who) {
+  return who.name;
+}
+
+fn main() {
+  on("say", listen);
+  on("say", filter, 1);
+  on("say", broken, 2);
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "events.glpc")), "events.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run main: %v", err)
+	}
+
+	rec := &recorder{}
+	id := interp.Events().On("say", rec, -1)
+
+	tests := []struct {
+		text   string
+		done   bool
+		calls  int
+		logged string
+	}{
+		{"hello", true, 1, "[alice: hello]"},
+		{"spam", false, 1, "[alice: hello]"},
+		{"bye", true, 2, "[alice: hello][alice: bye]"},
+	}
+
+	for i, tt := range tests {
+		done, errs := interp.Emit("say", &String{Value: "alice"}, &String{Value: tt.text})
+		if done != tt.done {
+			t.Errorf("test %d: wrong result. expected=%t, got=%t", i+1, tt.done, done)
+		}
+		if len(errs) != 1 {
+			t.Errorf("test %d: expected the broken handler to fail. got=%v", i+1, errs)
+		}
+		if len(rec.calls) != tt.calls {
+			t.Errorf("test %d: wrong number of host calls. expected=%d, got=%d", i+1, tt.calls, len(rec.calls))
+		}
+		if logged := env.GetString("said").String(); logged != tt.logged {
+			t.Errorf("test %d: wrong handlers called. expected=%q, got=%q", i+1, tt.logged, logged)
+		}
+	}
+
+	if !interp.Events().Off(id) {
+		t.Errorf("expected the host handler to be removed")
+	}
+	interp.Emit("say", &String{Value: "alice"}, &String{Value: "again"})
+	if len(rec.calls) != 2 {
+		t.Errorf("removed handler was called")
+	}
+}
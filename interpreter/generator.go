@@ -0,0 +1,264 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// closeGenerator is returned by a yield when its generator is closed, to unwind the rest of its body.
+var closeGenerator = errors.New("unexpected 'yield' in a closed generator")
+
+// Generator is returned by calling a function which yields. Its body runs on a goroutine of its own, with a fork of
+// the interpreter which called the function, but only while a caller waits in Resume, so the body and its caller
+// never run at the same time.
+type Generator struct {
+	*coroutine
+}
+
+// coroutine is the state of a Generator shared with the goroutine running its body. The goroutine does not refer to
+// the Generator itself, so a generator which is no longer reachable can be closed when it is collected. It cannot be
+// running then, so it is closed without the lock, which a script may hold for as long as it runs.
+type coroutine struct {
+	fn      *Function
+	env     *object.Environment
+	inter   *Interpreter
+	resume  chan object.Object // Values passed to a suspended yield. Closed to close the generator.
+	results chan genResult     // Values yielded, and finally returned, by the body.
+	resumer *Task              // The task which last resumed the generator, or nil for the host.
+	started bool
+	running bool
+	done    bool
+}
+
+// genResult is a value yielded by the body of a generator, or its return value and error when done is set.
+type genResult struct {
+	value object.Object
+	err   error
+	done  bool
+}
+
+func newGenerator(inter *Interpreter, fn *Function, env *object.Environment) *Generator {
+	g := &Generator{&coroutine{
+		fn:      fn,
+		env:     env,
+		inter:   inter,
+		resume:  make(chan object.Object),
+		results: make(chan genResult),
+	}}
+	runtime.SetFinalizer(g, func(g *Generator) { g.close() })
+	return g
+}
+
+func (g *Generator) Type() object.Type { return object.Generator }
+func (g *Generator) String() string    { return "<generator " + g.fn.declaration.Name.Lexeme + ">" }
+
+// Done reports whether the body of the generator has returned, failed or been closed.
+func (g *Generator) Done() bool { return g.done }
+
+// Resume runs the generator until its next yield and returns the value yielded. value becomes the result of the
+// yield the generator is suspended at, and is ignored when the generator has not started. When the body returns,
+// Resume returns its return value, or its error, and the generator is done. Resuming a generator which is done
+// returns null. Resume is for the host; it waits for any task running script code to stop first.
+func (g *Generator) Resume(value object.Object) (object.Object, error) {
+	g.inter.tasks.enter()
+	defer g.inter.tasks.leave()
+
+	return g.next(nil, value)
+}
+
+// next resumes the generator from script code running as resumer, which already holds the lock the body runs under.
+func (g *Generator) next(resumer *Task, value object.Object) (object.Object, error) {
+	switch {
+	case g.done:
+		return NullOb, nil
+	case g.running:
+		return nil, BIError("Generator " + g.fn.declaration.Name.Lexeme + " is already running.")
+	}
+
+	g.running = true
+	g.resumer = resumer
+	if !g.started {
+		g.started = true
+		go g.run()
+	} else {
+		g.resume <- value
+	}
+
+	r := <-g.results
+	g.running = false
+	g.done = r.done
+	return r.value, r.err
+}
+
+// Close finishes a generator without running the rest of its body, so that its goroutine exits. It does nothing if
+// the generator is done or running. Close is for the host; it waits for any task running script code to stop first.
+func (g *Generator) Close() {
+	g.inter.tasks.mu.Lock()
+	defer g.inter.tasks.mu.Unlock()
+
+	g.close()
+}
+
+// close closes the generator from script code, or once it is unreachable. The body unwinds without running any more
+// script code, so it does not need the lock.
+func (c *coroutine) close() {
+	if c.done || c.running {
+		return
+	}
+
+	c.done = true
+	if c.started {
+		close(c.resume)
+		<-c.results
+	}
+}
+
+// run executes the body of the generator, on its own goroutine.
+func (c *coroutine) run() {
+	inter := c.inter.fork()
+	inter.gen = c
+
+	value, err := c.fn.run(inter, c.env)
+	if err == closeGenerator {
+		value, err = NullOb, nil
+	}
+	c.results <- genResult{value: value, err: err, done: true}
+}
+
+// yield suspends the body of the generator, passing value to Resume, and returns the value it is resumed with.
+func (c *coroutine) yield(value object.Object) (object.Object, error) {
+	c.results <- genResult{value: value}
+
+	v, ok := <-c.resume
+	if !ok {
+		return nil, closeGenerator
+	}
+	if v == nil {
+		v = NullOb
+	}
+	return v, nil
+}
+
+func (inter *Interpreter) VisitYieldExpr(expr *object.YieldExpr) (object.Object, error) {
+	value, err := inter.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if inter.gen == nil {
+		return nil, object.NewRuntimeError(expr.Keyword, "Cannot yield outside of a generator.")
+	}
+	return inter.gen.yield(value)
+}
+
+// StartCoroutine calls the generator function name, defined at the top-level of env, with args and returns the
+// generator for the host to resume, such as once each game tick.
+func (inter *Interpreter) StartCoroutine(env *object.Environment, name string, args ...object.Object) (*Generator, error) {
+	inter.tasks.enter()
+	defer inter.tasks.leave()
+
+	inter.env = env
+
+	fn, ok := inter.env.GetString(name).(*Function)
+	if !ok {
+		return nil, fmt.Errorf("Unable to locate %s function.", name)
+	}
+	if !fn.declaration.Generator {
+		return nil, fmt.Errorf("Found %s, but it does not yield.", name)
+	}
+
+	value, err := fn.Call(inter, args)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*Generator), nil
+}
+
+// Scheduler resumes coroutines as a game clock ticks, so that behavior spread over many ticks can be written as a
+// sequence of steps. A coroutine which yields an integer n is resumed again after n ticks, and one which yields any
+// other value on the next tick. Coroutines are removed once they are done.
+type Scheduler struct {
+	tasks []*task
+}
+
+// task is a coroutine added to a Scheduler, and the number of ticks until it is next resumed.
+type task struct {
+	gen  *Generator
+	wait int
+}
+
+// Add schedules gen to be started on the next tick.
+func (s *Scheduler) Add(gen *Generator) {
+	s.tasks = append(s.tasks, &task{gen: gen, wait: 1})
+}
+
+// Len returns the number of coroutines which are not done.
+func (s *Scheduler) Len() int {
+	return len(s.tasks)
+}
+
+// Tick advances the clock by one tick and resumes each coroutine which is due, in the order they were added. A
+// coroutine which fails is removed, and the errors of all which failed are returned.
+func (s *Scheduler) Tick() []error {
+	var errs []error
+	tasks := s.tasks[:0]
+	for _, t := range s.tasks {
+		if t.wait--; t.wait > 0 {
+			tasks = append(tasks, t)
+			continue
+		}
+
+		value, err := t.gen.Resume(NullOb)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if t.gen.Done() {
+			continue
+		}
+
+		t.wait = 1
+		if n, ok := value.(*Number); ok && n.IsInt && n.Big == nil && n.Int > 1 {
+			t.wait = n.Int
+		}
+		tasks = append(tasks, t)
+	}
+	s.tasks = tasks
+
+	return errs
+}
+
+// bNext resumes a generator, passing it the optional second argument as the result of its yield, and returns the
+// value it yields next. Once the generator returns it is done, and next returns its return value, then null.
+func bNext(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'next' expects a generator and an optional value.")
+	}
+
+	g, ok := args[0].(*Generator)
+	if !ok {
+		return NullOb, BIError("'next' argument must be of a type GENERATOR.")
+	}
+
+	var value object.Object = NullOb
+	if len(args) == 2 {
+		value = args[1]
+	}
+	return g.next(interp.currentTask(), value)
+}
+
+// bIsDone reports whether a generator has returned.
+func bIsDone(interp *Interpreter, args []object.Object) (object.Object, error) {
+	g, ok := args[0].(*Generator)
+	if !ok {
+		return NullOb, BIError("'isDone' argument must be of a type GENERATOR.")
+	}
+
+	if g.Done() {
+		return True, nil
+	}
+	return False, nil
+}
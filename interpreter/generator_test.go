@@ -0,0 +1,210 @@
+package interpreter
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+)
+
+func TestScheduler(t *testing.T) {
+	input := `
+fn npc(name) {
+  debugPrint(name, "walks to the tavern");
+  yield 3;
+  debugPrint(name, "speaks");
+  yield;
+  debugPrint(name, "sits");
+}
+
+fn broken() {
+  yield;
+  missing();
+}
+
+fn plain() {}
+`
+
+	var stdout, stderr bytes.Buffer
+	interp := New()
+	interp.SetOutput(&stdout, &stderr)
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "npc.glpc")), "npc.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+
+	if _, err := interp.StartCoroutine(env, "plain"); err == nil {
+		t.Errorf("expected an error starting a function which does not yield")
+	}
+
+	guard, err := interp.StartCoroutine(env, "npc", &String{Value: "Guard"})
+	if err != nil {
+		t.Fatalf("unable to start coroutine: %v", err)
+	}
+	fail, err := interp.StartCoroutine(env, "broken")
+	if err != nil {
+		t.Fatalf("unable to start coroutine: %v", err)
+	}
+
+	var s Scheduler
+	s.Add(guard)
+	s.Add(fail)
+
+	expected := []struct {
+		output string
+		errs   int
+		tasks  int
+	}{
+		{"Guard walks to the tavern\n", 0, 2},
+		{"", 1, 1},
+		{"", 0, 1},
+		{"Guard speaks\n", 0, 1},
+		{"Guard sits\n", 0, 0},
+		{"", 0, 0},
+	}
+
+	for i, expect := range expected {
+		stdout.Reset()
+		errs := s.Tick()
+
+		if stdout.String() != expect.output {
+			t.Errorf("tick %d: wrong output. expected=%q, got=%q", i+1, expect.output, stdout.String())
+		}
+		if len(errs) != expect.errs {
+			t.Errorf("tick %d: wrong number of errors. expected=%d, got=%v", i+1, expect.errs, errs)
+		}
+		if s.Len() != expect.tasks {
+			t.Errorf("tick %d: wrong number of coroutines. expected=%d, got=%d", i+1, expect.tasks, s.Len())
+		}
+	}
+
+	if !guard.Done() {
+		t.Errorf("expected the coroutine to be done")
+	}
+}
+
+func TestGeneratorClose(t *testing.T) {
+	input := `
+var log = "";
+
+fn steps() {
+  log = log + "start";
+  yield 1;
+  log = log + " never";
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "close.glpc")), "close.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+
+	g, err := interp.StartCoroutine(env, "steps")
+	if err != nil {
+		t.Fatalf("unable to start coroutine: %v", err)
+	}
+	if _, err := g.Resume(NullOb); err != nil {
+		t.Fatalf("unable to resume coroutine: %v", err)
+	}
+
+	g.Close()
+	if !g.Done() {
+		t.Errorf("expected a closed generator to be done")
+	}
+	if value, err := g.Resume(NullOb); err != nil || value != NullOb {
+		t.Errorf("expected resuming a closed generator to return null. got=%v, %v", value, err)
+	}
+
+	if log := env.GetString("log").String(); log != "start" {
+		t.Errorf("wrong steps run. expected=%q, got=%q", "start", log)
+	}
+}
+
+func TestGeneratorFinalizer(t *testing.T) {
+	input := `
+fn naturals() {
+  var n = 0;
+  while (true) {
+    yield n;
+    n++;
+  }
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "finalize.glpc")), "finalize.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+
+	g, err := interp.StartCoroutine(env, "naturals")
+	if err != nil {
+		t.Fatalf("unable to start coroutine: %v", err)
+	}
+	if _, err := g.Resume(NullOb); err != nil {
+		t.Fatalf("unable to resume coroutine: %v", err)
+	}
+	resume := g.resume
+	g = nil
+
+	// A running script holds the lock, which must not stop an abandoned generator being closed.
+	interp.tasks.mu.Lock()
+	defer interp.tasks.mu.Unlock()
+
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		select {
+		case _, ok := <-resume:
+			if !ok {
+				return
+			}
+		default:
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected an unreachable generator to be closed")
+}
+
+func TestForInClosesGenerator(t *testing.T) {
+	input := `
+fn naturals() {
+  var n = 0;
+  while (true) {
+    yield n;
+    n++;
+  }
+}
+
+fn main() {
+  for (var i = 0; i < 200; i++) {
+    for (var n in naturals()) {
+      if (n == 2) {
+        break;
+      }
+    }
+  }
+}
+`
+
+	before := runtime.NumGoroutine()
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "loop.glpc")), "loop.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run script: %v", err)
+	}
+
+	// The goroutines of the closed generators may take a moment to exit after returning their last result.
+	for i := 0; i < 50 && runtime.NumGoroutine() > before+10; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before+10 {
+		t.Errorf("expected the loop to close the generators it started. goroutines before=%d, after=%d", before, n)
+	}
+}
@@ -1,17 +1,28 @@
 package interpreter
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/butlermatt/glpc/lexer"
 	"github.com/butlermatt/glpc/object"
 	"github.com/butlermatt/glpc/parser"
+	"io"
 	"io/ioutil"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"time"
 )
 
 var BreakError = errors.New("unexpected 'break' outside of loop")
 var ContinueError = errors.New("unexpected 'continue' outside of loop")
 
+// skipChain is returned by a ?. whose object is null, to skip the rest of the chain of calls and properties up to the
+// enclosing OptionalExpr.
+var skipChain = errors.New("unexpected '?.' outside of a chain")
+
 type ReturnError struct {
 	object.RuntimeError
 	Value object.Object
@@ -29,38 +40,89 @@ type Interpreter struct {
 	local   map[object.Expr]int
 	env     *object.Environment
 	globals *object.Environment
+	stdout  io.Writer
+	stderr  io.Writer
+	rand    *rand.Rand
+	gen     *coroutine // The generator whose body is running, if any.
+	task    *Task      // The spawned task running on the interpreter, or nil for a call from the host.
+	tasks   *taskGroup
+	events  *EventBus
+	clock   *Clock
 }
 
 func New() *Interpreter {
 	glob := object.GetGlobal()
 	glob = SetupGlobal(glob)
-	return &Interpreter{globals: glob}
+	return &Interpreter{
+		globals: glob,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		tasks:   newTaskGroup(),
+		events:  newEventBus(),
+		clock:   &Clock{},
+	}
+}
+
+// fork returns a copy of the interpreter to run code on another goroutine. The copy shares the globals, output,
+// random number generator, lock, event bus and clock, but keeps track of its own current environment.
+func (inter *Interpreter) fork() *Interpreter {
+	f := *inter
+	return &f
+}
+
+// SetSeed reseeds the random number generator used by the random module. Interpreters given the same seed produce
+// the same sequence of random values. By default the generator is seeded from the current time.
+func (inter *Interpreter) SetSeed(seed int64) {
+	inter.rand.Seed(seed)
+}
+
+// SetOutput changes where script output and diagnostics are written. By default they go to os.Stdout and os.Stderr.
+func (inter *Interpreter) SetOutput(stdout, stderr io.Writer) {
+	inter.stdout = stdout
+	inter.stderr = stderr
 }
 
 func (inter *Interpreter) RunMain(env *object.Environment) error {
+	return inter.RunFunction(env, "main")
+}
+
+// RunFunction calls the function name, defined at the top-level of env, without any arguments.
+func (inter *Interpreter) RunFunction(env *object.Environment, name string) error {
+	inter.tasks.enter()
+	defer inter.tasks.leave()
+
 	inter.env = env
 
-	mnFn := inter.env.GetString("main")
-	if mnFn == nil {
-		return fmt.Errorf("Unable to locate main function.")
+	fnObj := inter.env.GetString(name)
+	if fnObj == nil {
+		return fmt.Errorf("Unable to locate %s function.", name)
 	}
 
-	if mnFn.Type() != object.Function {
-		return fmt.Errorf("Found main, but it was not a function.")
+	if fnObj.Type() != object.Function {
+		return fmt.Errorf("Found %s, but it was not a function.", name)
 	}
 
-	mainFunc := mnFn.(*Function)
-	_, err := mainFunc.Call(inter, nil)
+	fn := fnObj.(*Function)
+	_, err := fn.Call(inter, nil)
 
 	return err
 }
 
 func (inter *Interpreter) Interpret(parser *parser.Parser, filename string) (*object.Environment, error) {
+	inter.tasks.enter()
+	defer inter.tasks.leave()
+
+	return inter.interpret(parser, filename)
+}
+
+// interpret runs the top-level of a file with the lock already held.
+func (inter *Interpreter) interpret(parser *parser.Parser, filename string) (*object.Environment, error) {
 	stmts, depth := parser.Parse()
 	errs := parser.Errors()
 	if len(errs) > 0 {
 		for _, err := range errs {
-			fmt.Printf("[Syntax error] %+v\n", err)
+			fmt.Fprintf(inter.stderr, "[Syntax error] %+v\n", err)
 		}
 		return nil, fmt.Errorf("%d syntax errors found.", len(errs))
 	}
@@ -85,6 +147,17 @@ func (inter *Interpreter) evaluate(expr object.Expr) (object.Object, error) {
 	return expr.Accept(inter)
 }
 
+// evaluateIn evaluates expr with env as the current environment.
+func (inter *Interpreter) evaluateIn(expr object.Expr, env *object.Environment) (object.Object, error) {
+	prevEnv := inter.env
+	inter.env = env
+
+	value, err := inter.evaluate(expr)
+
+	inter.env = prevEnv
+	return value, err
+}
+
 func (inter *Interpreter) executeBlock(stmts []object.Stmt, env *object.Environment) error {
 	prevEnv := inter.env
 	inter.env = env
@@ -133,27 +206,161 @@ func (inter *Interpreter) VisitClassStmt(stmt *object.ClassStmt) error {
 			return object.NewRuntimeError(stmt.Super.Name, "Superclass must be a class.")
 		}
 		superClass = sc.(*Class)
+	}
+
+	if len(stmt.Traits) > 0 {
+		traits := make([]*Trait, len(stmt.Traits))
+		for i, expr := range stmt.Traits {
+			t, err := inter.evaluate(expr)
+			if err != nil {
+				return err
+			}
+			if t.Type() != object.Trait {
+				return object.NewRuntimeError(expr.Name, "Can only mix in traits.")
+			}
+			traits[i] = t.(*Trait)
+			for _, prev := range traits[:i] {
+				if prev == traits[i] {
+					return object.NewRuntimeError(expr.Name, "Trait is mixed in more than once.")
+				}
+			}
+		}
+
+		if err := checkTraitConflicts(stmt, traits); err != nil {
+			return err
+		}
+
+		// The first trait listed is searched first, so it is the closest to the class.
+		for i := len(traits) - 1; i >= 0; i-- {
+			superClass = traits[i].mixin(superClass)
+		}
+	}
+
+	if superClass != nil {
 		prevEnv = inter.env
 		inter.env = object.NewEnclosedEnvironment(inter.env)
 		inter.env.DefineString("super", superClass)
 	}
 
-	var methods = make(map[string]*Function)
-	for _, meth := range stmt.Methods {
-		methods[meth.Name.Lexeme] = NewFunction(meth, inter.env, meth.Name.Lexeme == "init")
+	klass := &Class{
+		Name:       stmt.Name.Lexeme,
+		superclass: superClass,
+		methods:    newMethods(stmt.Methods, inter.env),
+		getters:    newMethods(stmt.Getters, inter.env),
+		setters:    newMethods(stmt.Setters, inter.env),
+		statics:    make(map[string]object.Object),
+		fields:     stmt.Fields,
+		closure:    inter.env,
+
+		staticMethods: newMethods(stmt.StaticMethods, inter.env),
+		staticFields:  stmt.StaticFields,
+	}
+
+	// Static fields are initialized in order once the class exists, with this bound to the class.
+	var err error
+	if len(stmt.StaticFields) > 0 {
+		env := object.NewEnclosedEnvironment(inter.env)
+		env.DefineString("this", klass)
+		for _, field := range stmt.StaticFields {
+			var value object.Object = NullOb
+			if field.Value != nil {
+				value, err = inter.evaluateIn(field.Value, env)
+				if err != nil {
+					break
+				}
+				if err = inter.checkType(field.Type, value, env, "Field "+field.Name.Lexeme); err != nil {
+					err = callError(field.Name, err)
+					break
+				}
+			}
+			klass.statics[field.Name.Lexeme] = value
+		}
 	}
 
-	klass := &Class{Name: stmt.Name.Lexeme, superclass: superClass, methods: methods}
 	if prevEnv != nil {
 		inter.env = prevEnv
 	}
 
 	inter.env.Assign(stmt.Name, klass)
+	return err
+}
+
+// newMethods creates the methods declared in a class or trait body, keyed by name, closing over env.
+func newMethods(decls []*object.FunctionStmt, env *object.Environment) map[string]*Function {
+	fns := make(map[string]*Function)
+	for _, decl := range decls {
+		fns[decl.Name.Lexeme] = NewFunction(decl, env, decl.Name.Lexeme == "init")
+	}
+	return fns
+}
+
+func (inter *Interpreter) VisitTraitStmt(stmt *object.TraitStmt) error {
+	inter.env.Define(stmt.Name, &Trait{Name: stmt.Name.Lexeme, decl: stmt, closure: inter.env})
 	return nil
 }
 
 func (inter *Interpreter) VisitContinueStmt(stmt *object.ContinueStmt) error { return ContinueError }
 
+func (inter *Interpreter) VisitDestructureStmt(stmt *object.DestructureStmt) error {
+	value, err := inter.evaluate(stmt.Value)
+	if err != nil {
+		return err
+	}
+
+	var values []object.Object
+	if stmt.Keyword.Type == lexer.LBrace {
+		values = make([]object.Object, len(stmt.Names))
+		for i, name := range stmt.Names {
+			if values[i], err = inter.getProperty(value, name); err != nil {
+				return err
+			}
+		}
+	} else if values, err = unpack(stmt.Keyword, value, len(stmt.Names), stmt.Rest != nil); err != nil {
+		return err
+	}
+
+	names := stmt.Names
+	if stmt.Rest != nil {
+		names = append(names[:len(names):len(names)], stmt.Rest)
+	}
+	for i, name := range names {
+		if stmt.Const {
+			err = inter.env.DefineConst(name, values[i])
+		} else {
+			inter.env.Define(name, values[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpack returns the n elements of the list value, reporting an error at tok if it is not a list of n elements. If
+// rest is true the list may be longer, and the elements after the first n are returned as a new list after them.
+func unpack(tok *lexer.Token, value object.Object, n int, rest bool) ([]object.Object, error) {
+	list, ok := value.(*List)
+	if !ok {
+		return nil, object.NewRuntimeError(tok, "Only a list can be unpacked, got "+typeName(value)+".")
+	}
+
+	if rest && len(list.Elements) < n {
+		return nil, object.NewRuntimeError(tok, fmt.Sprintf("Expected at least %d values but got %d.", n, len(list.Elements)))
+	}
+	if !rest && len(list.Elements) != n {
+		return nil, object.NewRuntimeError(tok, fmt.Sprintf("Expected %d values but got %d.", n, len(list.Elements)))
+	}
+
+	values := make([]object.Object, n, n+1)
+	copy(values, list.Elements)
+	if rest {
+		remaining := make([]object.Object, len(list.Elements)-n)
+		copy(remaining, list.Elements[n:])
+		values = append(values, &List{Elements: remaining})
+	}
+	return values, nil
+}
+
 func (inter *Interpreter) VisitExpressionStmt(stmt *object.ExpressionStmt) error {
 	_, err := inter.evaluate(stmt.Expression)
 	return err
@@ -182,6 +389,130 @@ func (inter *Interpreter) VisitIfStmt(stmt *object.IfStmt) error {
 	return nil
 }
 
+// VisitMatchStmt runs the body of the first case which matches the value of the subject, in a new environment
+// holding the variables bound by its pattern. If no case matches and there is no default case, nothing is run.
+func (inter *Interpreter) VisitMatchStmt(stmt *object.MatchStmt) error {
+	value, err := inter.evaluate(stmt.Subject)
+	if err != nil {
+		return err
+	}
+
+	for _, mc := range stmt.Cases {
+		env := object.NewEnclosedEnvironment(inter.env)
+		ok, err := inter.matchCase(mc, value, env)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return inter.executeBlock([]object.Stmt{mc.Body}, env)
+		}
+	}
+
+	return nil
+}
+
+// matchCase reports whether value matches one of the patterns of mc and its guard is truthy, binding the variables
+// of the pattern in env. The default case matches every value.
+func (inter *Interpreter) matchCase(mc *object.MatchCase, value object.Object, env *object.Environment) (bool, error) {
+	matched := mc.Patterns == nil
+	var err error
+	for _, pat := range mc.Patterns {
+		if matched, err = inter.matchPattern(pat, value, env); err != nil || matched {
+			break
+		}
+	}
+	if err != nil || !matched || mc.Guard == nil {
+		return matched, err
+	}
+
+	guard, err := inter.evaluateIn(mc.Guard, env)
+	if err != nil {
+		return false, err
+	}
+	return isTruthy(guard), nil
+}
+
+// matchPattern reports whether value matches pat, binding the variables of the pattern in env.
+func (inter *Interpreter) matchPattern(pat *object.Pattern, value object.Object, env *object.Environment) (bool, error) {
+	switch pat.Kind {
+	case object.LiteralPattern:
+		literal, err := inter.evaluate(pat.Value)
+		if err != nil {
+			return false, err
+		}
+		return inter.equal(pat.Token, value, literal)
+	case object.BindPattern:
+		bind(pat.Name, value, env)
+		return true, nil
+	case object.TypePattern:
+		ok, err := inter.hasType(pat.Type, value, env)
+		if ok {
+			bind(pat.Name, value, env)
+		}
+		return ok, err
+	case object.ListPattern:
+		list, ok := value.(*List)
+		if !ok || len(list.Elements) < len(pat.Elements) || pat.Rest == nil && len(list.Elements) != len(pat.Elements) {
+			return false, nil
+		}
+		for i, elem := range pat.Elements {
+			if ok, err := inter.matchPattern(elem, list.Elements[i], env); !ok || err != nil {
+				return false, err
+			}
+		}
+		if pat.Rest != nil {
+			rest := make([]object.Object, len(list.Elements)-len(pat.Elements))
+			copy(rest, list.Elements[len(pat.Elements):])
+			bind(pat.Rest, &List{Elements: rest}, env)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// VisitMultiAssignStmt evaluates every value before assigning any of them, so that a, b = b, a swaps the variables.
+// A single value assigned to several targets is a list, which is unpacked.
+func (inter *Interpreter) VisitMultiAssignStmt(stmt *object.MultiAssignStmt) error {
+	values := make([]object.Object, len(stmt.Values))
+	for i, expr := range stmt.Values {
+		value, err := inter.evaluate(expr)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+
+	if len(values) != len(stmt.Targets) {
+		var err error
+		if values, err = unpack(stmt.Equals, values[0], len(stmt.Targets), false); err != nil {
+			return err
+		}
+	}
+
+	for i, target := range stmt.Targets {
+		value := values[i]
+		var err error
+		switch t := target.(type) {
+		case *object.AssignExpr:
+			err = inter.assignVariable(t, value)
+		case *object.SetExpr:
+			_, err = inter.set(t, func() (object.Object, error) { return value, nil })
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind defines a variable bound by a pattern in env, unless it is the wildcard _.
+func bind(name *lexer.Token, value object.Object, env *object.Environment) {
+	if name.Lexeme != "_" {
+		env.Define(name, value)
+	}
+}
+
 func (inter *Interpreter) VisitImportStmt(stmt *object.ImportStmt) error {
 	str, ok := stmt.Other.(*object.StringExpr)
 	if !ok {
@@ -206,7 +537,13 @@ func (inter *Interpreter) VisitImportStmt(stmt *object.ImportStmt) error {
 	l := lexer.New(file, str.Value)
 	p := parser.New(l)
 	interpreter := New()
-	oEnv, err = interpreter.Interpret(p, str.Value)
+	interpreter.SetOutput(inter.stdout, inter.stderr)
+	interpreter.rand = inter.rand
+	interpreter.task = inter.task
+	interpreter.tasks = inter.tasks
+	interpreter.events = inter.events
+	interpreter.clock = inter.clock
+	oEnv, err = interpreter.interpret(p, str.Value)
 
 	inter.env.Copy(oEnv)
 
@@ -253,7 +590,7 @@ func (inter *Interpreter) VisitForStmt(stmt *object.ForStmt) error {
 		}
 
 		if stmt.Increment != nil {
-			_, err := inter.evaluate(stmt.Increment)
+			_, err = inter.evaluate(stmt.Increment)
 			if err != nil {
 				break
 			}
@@ -266,6 +603,103 @@ func (inter *Interpreter) VisitForStmt(stmt *object.ForStmt) error {
 	return err
 }
 
+// VisitForInStmt runs the body of the loop once for each element of a list, character of a string, value yielded by
+// a generator or value received from a channel until it is closed, with the element bound to the loop variable in a
+// new environment. Elements added to a list by the body are included.
+func (inter *Interpreter) VisitForInStmt(stmt *object.ForInStmt) error {
+	iterable, owned, err := inter.iterable(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+
+	var next func() (object.Object, bool, error)
+	switch it := iterable.(type) {
+	case *List:
+		i := 0
+		next = func() (object.Object, bool, error) {
+			if i >= len(it.Elements) {
+				return nil, false, nil
+			}
+			i++
+			return it.Elements[i-1], true, nil
+		}
+	case *String:
+		runes := []rune(it.Value)
+		next = func() (object.Object, bool, error) {
+			if len(runes) == 0 {
+				return nil, false, nil
+			}
+			r := runes[0]
+			runes = runes[1:]
+			return &String{Value: string(r)}, true, nil
+		}
+	case *Generator:
+		// Nothing else can resume a generator the loop started, so it is closed when the loop ends early.
+		if owned {
+			defer it.close()
+		}
+		next = func() (object.Object, bool, error) {
+			value, err := it.next(inter.currentTask(), NullOb)
+			if err != nil {
+				return nil, false, callError(stmt.Keyword, err)
+			}
+			return value, !it.Done(), nil
+		}
+	case *Channel:
+		next = func() (object.Object, bool, error) {
+			value, ok, err := it.recv(inter)
+			if err != nil {
+				return nil, false, callError(stmt.Keyword, err)
+			}
+			return value, ok, nil
+		}
+	default:
+		return object.NewRuntimeError(stmt.Keyword, "Can only loop over a list, string, generator or channel, got "+typeName(iterable)+".")
+	}
+
+	prev := inter.env
+	for {
+		value, ok, err := next()
+		if err != nil || !ok {
+			return err
+		}
+
+		env := object.NewEnclosedEnvironment(prev)
+		env.Define(stmt.Name, value)
+		inter.env = env
+		err = inter.execute(stmt.Body)
+		inter.env = prev
+
+		if err == BreakError {
+			return nil
+		} else if err != nil && err != ContinueError {
+			return err
+		}
+	}
+}
+
+// iterable evaluates the iterable of a for-in loop. It also reports whether the iterable is a generator which was
+// started by the loop, by calling a function which yields.
+func (inter *Interpreter) iterable(expr object.Expr) (object.Object, bool, error) {
+	call, ok := expr.(*object.CallExpr)
+	if !ok {
+		value, err := inter.evaluate(expr)
+		return value, false, err
+	}
+
+	function, args, err := inter.callee(call)
+	if err != nil {
+		return nil, false, err
+	}
+	value, err := function.Call(inter, args)
+	if err != nil {
+		return nil, false, callError(call.Paren, err)
+	}
+
+	fn, ok := function.(*Function)
+	return value, ok && fn.declaration.Generator, nil
+}
+
 func (inter *Interpreter) VisitReturnStmt(stmt *object.ReturnStmt) error {
 	var value object.Object
 	var err error
@@ -281,16 +715,23 @@ func (inter *Interpreter) VisitReturnStmt(stmt *object.ReturnStmt) error {
 }
 
 func (inter *Interpreter) VisitVarStmt(stmt *object.VarStmt) error {
-	var value object.Object
+	var value object.Object = NullOb
 	var err error
 
+	// A typed variable declared without an initializer holds null until it is first assigned.
 	if stmt.Value != nil {
 		value, err = inter.evaluate(stmt.Value)
 		if err != nil {
 			return err
 		}
+		if err := inter.checkType(stmt.Type, value, inter.env, "Variable "+stmt.Name.Lexeme); err != nil {
+			return callError(stmt.Name, err)
+		}
 	}
 
+	if stmt.Const {
+		return inter.env.DefineConst(stmt.Name, value)
+	}
 	inter.env.Define(stmt.Name, value)
 	return nil
 }
@@ -301,17 +742,22 @@ func (inter *Interpreter) VisitAssignExpr(expr *object.AssignExpr) (object.Objec
 		return nil, err
 	}
 
-	if dist, ok := inter.local[expr]; ok {
-		err = inter.env.AssignAt(dist, expr.Name, value)
-	} else {
-		err = inter.env.Assign(expr.Name, value)
+	if err := inter.assignVariable(expr, value); err != nil {
+		return nil, err
 	}
+	return value, nil
+}
 
-	if err != nil {
-		return nil, err
+// assignVariable checks value against the type of the variable assigned by expr, then stores it.
+func (inter *Interpreter) assignVariable(expr *object.AssignExpr, value object.Object) error {
+	if err := inter.checkType(expr.Type, value, inter.env, "Variable "+expr.Name.Lexeme); err != nil {
+		return callError(expr.Name, err)
 	}
 
-	return value, nil
+	if dist, ok := inter.local[expr]; ok {
+		return inter.env.AssignAt(dist, expr.Name, value)
+	}
+	return inter.env.Assign(expr.Name, value)
 }
 
 func (inter *Interpreter) VisitBinaryExpr(expr *object.BinaryExpr) (object.Object, error) {
@@ -324,32 +770,60 @@ func (inter *Interpreter) VisitBinaryExpr(expr *object.BinaryExpr) (object.Objec
 		return nil, err
 	}
 
-	switch expr.Operator.Type {
+	return inter.binary(expr.Operator, left, right)
+}
+
+// binary applies the binary operator oper to the values of its operands.
+func (inter *Interpreter) binary(oper *lexer.Token, left, right object.Object) (object.Object, error) {
+	if left.Type() == object.Instance || right.Type() == object.Instance {
+		if value, ok, err := inter.binaryOverload(oper, left, right); ok {
+			return value, err
+		}
+	}
+
+	switch oper.Type {
 	case lexer.Greater, lexer.GreaterEq, lexer.Less, lexer.LessEq:
-		return numberComparisonOperation(expr.Operator, left, right)
-	case lexer.Minus, lexer.Star, lexer.Slash, lexer.TildSlash, lexer.Percent:
-		return numberMathOperation(expr.Operator, left, right)
+		return numberComparisonOperation(oper, left, right)
+	case lexer.Minus, lexer.Star, lexer.StarStar, lexer.Slash, lexer.TildSlash, lexer.Percent:
+		return numberMathOperation(oper, left, right)
+	case lexer.Amp, lexer.Pipe, lexer.Caret, lexer.LessLess, lexer.GreaterGreater:
+		return bitwiseOperation(oper, left, right)
 	case lexer.EqualEq:
-		return isEqual(expr.Operator, left, right)
+		return isEqual(oper, left, right)
 	case lexer.BangEq:
-		b, err := isEqual(expr.Operator, left, right)
+		b, err := isEqual(oper, left, right)
 		if err != nil {
 			return nil, err
 		}
-		b.Value = !b.Value
-		return b, nil
+		if b == True {
+			return False, nil
+		}
+		return True, nil
 	case lexer.Plus:
 		if left.Type() == object.Number && right.Type() == object.Number {
-			return numberMathOperation(expr.Operator, left, right)
+			return numberMathOperation(oper, left, right)
 		}
 		if left.Type() == object.String && right.Type() == object.String {
 			l := left.(*String)
 			r := right.(*String)
 			return &String{Value: l.Value + r.Value}, nil
 		}
+		// A string may be joined with an instance, which is converted as it would be printed.
+		if left.Type() == object.String && right.Type() == object.Instance ||
+			left.Type() == object.Instance && right.Type() == object.String {
+			l, err := inter.stringify(oper, left)
+			if err != nil {
+				return nil, err
+			}
+			r, err := inter.stringify(oper, right)
+			if err != nil {
+				return nil, err
+			}
+			return &String{Value: l + r}, nil
+		}
 	}
 
-	return nil, object.NewRuntimeError(expr.Operator, fmt.Sprintf("No known operations for %s %s %s", left.Type(), expr.Operator.Lexeme, right.Type()))
+	return nil, object.NewRuntimeError(oper, fmt.Sprintf("No known operations for %s %s %s", left.Type(), oper.Lexeme, right.Type()))
 }
 
 func numberComparisonOperation(oper *lexer.Token, left, right object.Object) (*Boolean, error) {
@@ -362,13 +836,13 @@ func numberComparisonOperation(oper *lexer.Token, left, right object.Object) (*B
 
 	var value bool
 	if l.IsInt && r.IsInt {
-		value = intComparison(oper.Lexeme, l.Int, r.Int)
-	} else if l.IsInt {
-		value = floatComparison(oper.Lexeme, float64(l.Int), r.Float)
-	} else if r.IsInt {
-		value = floatComparison(oper.Lexeme, l.Float, float64(r.Int))
+		if l.Big != nil || r.Big != nil {
+			value = intComparison(oper.Lexeme, l.bigInt().Cmp(r.bigInt()), 0)
+		} else {
+			value = intComparison(oper.Lexeme, l.Int, r.Int)
+		}
 	} else {
-		value = floatComparison(oper.Lexeme, l.Float, r.Float)
+		value = floatComparison(oper.Lexeme, l.float(), r.float())
 	}
 
 	if value {
@@ -416,6 +890,28 @@ func floatComparison(oper string, left, right float64) bool {
 	return false
 }
 
+// DivisionByZero is returned by the numeric operations when the right operand of a division or modulo is zero.
+var DivisionByZero = errors.New("Division by zero.")
+
+// NegativeShift is returned by the shift operations when the shift count is negative.
+var NegativeShift = errors.New("Shift count must not be negative.")
+
+// PowerTooLarge is returned when an integer power would have more than maxPowBits bits.
+var PowerTooLarge = errors.New("Result of the power is too large.")
+
+// maxPowBits is the most bits an integer power may have. Larger powers would take too long to calculate and use too
+// much memory.
+const maxPowBits = 1 << 20
+
+// intPow raises base to the non-negative power exp exactly.
+func intPow(base, exp *big.Int) (*Number, error) {
+	// The result has at least exp * (bits - 1) bits, so only 0, 1 and -1 can be raised to any power.
+	if bits := base.BitLen(); bits > 1 && (!exp.IsInt64() || exp.Int64() > maxPowBits/int64(bits-1)) {
+		return nil, PowerTooLarge
+	}
+	return newBigNumber(new(big.Int).Exp(base, exp, nil)), nil
+}
+
 func numberMathOperation(oper *lexer.Token, left, right object.Object) (*Number, error) {
 	if left.Type() != object.Number || right.Type() != object.Number {
 		return nil, object.NewRuntimeError(oper, "Operands must be numbers.")
@@ -424,19 +920,18 @@ func numberMathOperation(oper *lexer.Token, left, right object.Object) (*Number,
 	l := left.(*Number)
 	r := right.(*Number)
 
-	number := &Number{}
+	var number *Number
+	var err error
 	if l.IsInt && r.IsInt {
-		if oper.Lexeme == "/" {
-			if l.Int%r.Int == 0 {
-				number.IsInt = true
-				number.Int = l.Int / r.Int
-			} else {
-				number.Float = float64(l.Int) / float64(r.Int)
-			}
-			return number, nil
+		if l.Big != nil || r.Big != nil {
+			number, err = bigOperation(oper.Lexeme, l.bigInt(), r.bigInt())
+		} else {
+			number, err = intOperation(oper.Lexeme, l.Int, r.Int)
+		}
+
+		if err != nil {
+			return nil, object.NewRuntimeError(oper, err.Error())
 		}
-		number.IsInt = true
-		number.Int = intOperation(oper.Lexeme, l.Int, r.Int)
 		return number, nil
 	}
 
@@ -444,50 +939,189 @@ func numberMathOperation(oper *lexer.Token, left, right object.Object) (*Number,
 		return nil, object.NewRuntimeError(oper, "Operands must both be integer values.")
 	}
 
-	if l.IsInt {
-		number.Float = floatOperation(oper.Lexeme, float64(l.Int), r.Float)
-	} else if r.IsInt {
-		number.Float = floatOperation(oper.Lexeme, l.Float, float64(r.Int))
-	} else {
-		number.Float = floatOperation(oper.Lexeme, l.Float, r.Float)
+	f, err := floatOperation(oper.Lexeme, l.float(), r.float())
+	if err != nil {
+		return nil, object.NewRuntimeError(oper, err.Error())
 	}
 
 	if oper.Lexeme == "~/" {
-		number.IsInt = true
-		number.Int = int(number.Float)
+		number, err = floatToInt(f)
+		if err != nil {
+			return nil, object.NewRuntimeError(oper, err.Error())
+		}
+		return number, nil
 	}
 
-	return number, nil
+	return &Number{Float: f}, nil
 }
 
-func intOperation(oper string, left, right int) int {
+// intOperation applies oper to two ints. Results which would overflow an int are calculated with bigOperation
+// instead, and dividing two integers which do not divide evenly produces a float.
+func intOperation(oper string, left, right int) (*Number, error) {
 	switch oper {
+	case "**":
+		// Powers are always calculated by bigOperation, which returns an int when the result fits in one.
+	case "&":
+		return &Number{IsInt: true, Int: left & right}, nil
+	case "|":
+		return &Number{IsInt: true, Int: left | right}, nil
+	case "^":
+		return &Number{IsInt: true, Int: left ^ right}, nil
+	case "<<":
+		if right < 0 {
+			return nil, NegativeShift
+		}
+		shifted := left << uint(right)
+		if shifted>>uint(right) != left {
+			break
+		}
+		return &Number{IsInt: true, Int: shifted}, nil
+	case ">>":
+		if right < 0 {
+			return nil, NegativeShift
+		}
+		return &Number{IsInt: true, Int: left >> uint(right)}, nil
 	case "+":
-		return left + right
+		sum := left + right
+		if (left >= 0) == (right >= 0) && (sum >= 0) != (left >= 0) {
+			break
+		}
+		return &Number{IsInt: true, Int: sum}, nil
 	case "-":
-		return left - right
+		diff := left - right
+		if (left >= 0) != (right >= 0) && (diff >= 0) != (left >= 0) {
+			break
+		}
+		return &Number{IsInt: true, Int: diff}, nil
 	case "*":
-		return left * right
+		prod := left * right
+		if left != 0 && (prod/left != right || left == -1 && right == math.MinInt) {
+			break
+		}
+		return &Number{IsInt: true, Int: prod}, nil
+	case "/":
+		if right == 0 {
+			return nil, DivisionByZero
+		}
+		if left%right != 0 {
+			return &Number{Float: float64(left) / float64(right)}, nil
+		}
+		if left == math.MinInt && right == -1 {
+			break
+		}
+		return &Number{IsInt: true, Int: left / right}, nil
 	case "~/":
-		return left / right
+		if right == 0 {
+			return nil, DivisionByZero
+		}
+		if left == math.MinInt && right == -1 {
+			break
+		}
+		return &Number{IsInt: true, Int: left / right}, nil
 	case "%":
-		return left % right
+		if right == 0 {
+			return nil, DivisionByZero
+		}
+		return &Number{IsInt: true, Int: left % right}, nil
+	default:
+		return nil, fmt.Errorf("Unknown operator %s.", oper)
 	}
-	return 0
+
+	return bigOperation(oper, big.NewInt(int64(left)), big.NewInt(int64(right)))
 }
 
-func floatOperation(oper string, left, right float64) float64 {
+// bigOperation applies oper to two arbitrary precision integers. Division and modulo truncate toward zero, the same
+// as they do for ints.
+func bigOperation(oper string, left, right *big.Int) (*Number, error) {
+	if right.Sign() == 0 && (oper == "/" || oper == "~/" || oper == "%") {
+		return nil, DivisionByZero
+	}
+
+	if right.Sign() < 0 && (oper == "<<" || oper == ">>") {
+		return nil, NegativeShift
+	}
+
+	result := new(big.Int)
 	switch oper {
+	case "**":
+		// A negative power is a fraction.
+		if right.Sign() < 0 {
+			l, _ := new(big.Float).SetInt(left).Float64()
+			r, _ := new(big.Float).SetInt(right).Float64()
+			return &Number{Float: math.Pow(l, r)}, nil
+		}
+		return intPow(left, right)
+	case "&":
+		result.And(left, right)
+	case "|":
+		result.Or(left, right)
+	case "^":
+		result.Xor(left, right)
+	case "<<":
+		if !right.IsUint64() || right.Uint64() > maxShift {
+			return nil, errors.New("Shift count is too large.")
+		}
+		result.Lsh(left, uint(right.Uint64()))
+	case ">>":
+		if !right.IsUint64() || right.Uint64() > maxShift {
+			right = big.NewInt(maxShift)
+		}
+		result.Rsh(left, uint(right.Uint64()))
 	case "+":
-		return left + right
+		result.Add(left, right)
 	case "-":
-		return left - right
+		result.Sub(left, right)
 	case "*":
-		return left * right
+		result.Mul(left, right)
 	case "/":
-		return left / right
+		rem := new(big.Int)
+		result.QuoRem(left, right, rem)
+		if rem.Sign() != 0 {
+			f, _ := new(big.Float).Quo(new(big.Float).SetInt(left), new(big.Float).SetInt(right)).Float64()
+			return &Number{Float: f}, nil
+		}
+	case "~/":
+		result.Quo(left, right)
+	case "%":
+		result.Rem(left, right)
+	default:
+		return nil, fmt.Errorf("Unknown operator %s.", oper)
 	}
-	return 0
+
+	return newBigNumber(result), nil
+}
+
+// maxShift is the largest shift count of the shift operations on big integers. Shifting left further would use too
+// much memory, and shifting right further gives the same result.
+const maxShift = 1 << 24
+
+// bitwiseOperation applies one of the bitwise or shift operators, which are only defined for integers.
+func bitwiseOperation(oper *lexer.Token, left, right object.Object) (*Number, error) {
+	l, lok := left.(*Number)
+	r, rok := right.(*Number)
+	if !lok || !rok || !l.IsInt || !r.IsInt {
+		return nil, object.NewRuntimeError(oper, "Operands must be integers.")
+	}
+	return numberMathOperation(oper, left, right)
+}
+
+func floatOperation(oper string, left, right float64) (float64, error) {
+	switch oper {
+	case "**":
+		return math.Pow(left, right), nil
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/", "~/":
+		if right == 0 {
+			return 0, DivisionByZero
+		}
+		return left / right, nil
+	}
+	return 0, fmt.Errorf("Unknown operator %s.", oper)
 }
 
 func isEqual(oper *lexer.Token, left, right object.Object) (*Boolean, error) {
@@ -501,7 +1135,8 @@ func isEqual(oper *lexer.Token, left, right object.Object) (*Boolean, error) {
 
 	switch left.Type() {
 	case object.Number:
-		return numberComparisonOperation(oper, left, right)
+		eq := lexer.NewToken(lexer.EqualEq, "==", oper.Filename, oper.Line)
+		return numberComparisonOperation(eq, left, right)
 	case object.Boolean:
 		if left == right {
 			return True, nil
@@ -516,8 +1151,11 @@ func isEqual(oper *lexer.Token, left, right object.Object) (*Boolean, error) {
 		return False, nil
 	}
 
-	// Shouldn't ever reach here
-	return nil, object.NewRuntimeError(oper, fmt.Sprintf("No known operations for %s %s %s", left.Type(), oper.Lexeme, right.Type()))
+	// Any other values, including instances, are only equal to themselves.
+	if left == right {
+		return True, nil
+	}
+	return False, nil
 }
 
 func (inter *Interpreter) VisitBooleanExpr(expr *object.BooleanExpr) (object.Object, error) {
@@ -528,36 +1166,88 @@ func (inter *Interpreter) VisitBooleanExpr(expr *object.BooleanExpr) (object.Obj
 }
 
 func (inter *Interpreter) VisitCallExpr(expr *object.CallExpr) (object.Object, error) {
-	callee, err := inter.evaluate(expr.Callee)
+	function, args, err := inter.callee(expr)
 	if err != nil {
 		return nil, err
 	}
 
+	value, err := function.Call(inter, args)
+	if err != nil {
+		return nil, callError(expr.Paren, err)
+	}
+
+	return value, nil
+}
+
+// callee evaluates the callee and arguments of a call, and checks the number of arguments.
+func (inter *Interpreter) callee(expr *object.CallExpr) (Callable, []object.Object, error) {
+	callee, err := inter.evaluate(expr.Callee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if method := specialMethod(callee, "__call"); method != nil {
+		callee = method
+	}
+
 	if callee.Type() != object.Function && callee.Type() != object.Class && callee.Type() != object.BuiltIn {
-		return nil, object.NewRuntimeError(expr.Paren, "Can only call functions and classes.")
+		return nil, nil, object.NewRuntimeError(expr.Paren, "Can only call functions and classes.")
 	}
 
 	var args []object.Object
 	for _, arg := range expr.Args {
 		a, err := inter.evaluate(arg)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		args = append(args, a)
 	}
 
 	function := callee.(Callable)
 
-	// Allow any number of args.
-	if function.Arity() == -1 {
-		return function.Call(inter, args)
+	// Named arguments may leave parameters with default values out, so it is left to the call to report any
+	// parameter which is missing.
+	if expr.Names != nil {
+		args, err = namedArgs(function, expr, args)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if min, max := arityRange(function); len(args) < min || max != -1 && len(args) > max {
+		return nil, nil, object.NewRuntimeError(expr.Paren, arityError(function, len(args)))
 	}
 
-	if len(args) != function.Arity() {
-		return nil, object.NewRuntimeError(expr.Paren, fmt.Sprintf("Expected %d arguments but got %d", function.Arity(), len(args)))
+	return function, args, nil
+}
+
+// callError attaches the location of a call to errors raised by builtins, which have no tokens of their own. Errors
+// are returned unchanged when paren is nil, leaving the call expression which invoked the builtin to locate them.
+func callError(paren *lexer.Token, err error) error {
+	if paren == nil {
+		return err
+	}
+
+	switch e := err.(type) {
+	case BIError:
+		return object.NewRuntimeError(paren, string(e))
+	case *AssertionError:
+		if e.Token == nil {
+			e.Token = paren
+		}
 	}
 
-	return function.Call(inter, args)
+	return err
+}
+
+func (inter *Interpreter) VisitConditionalExpr(expr *object.ConditionalExpr) (object.Object, error) {
+	cond, err := inter.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(cond) {
+		return inter.evaluate(expr.Then)
+	}
+	return inter.evaluate(expr.Else)
 }
 
 func (inter *Interpreter) VisitGetExpr(expr *object.GetExpr) (object.Object, error) {
@@ -566,12 +1256,30 @@ func (inter *Interpreter) VisitGetExpr(expr *object.GetExpr) (object.Object, err
 		return nil, err
 	}
 
+	if expr.Optional && obj.Type() == object.Null {
+		return nil, skipChain
+	}
+
+	return inter.getProperty(obj, expr.Name)
+}
+
+// getProperty returns the property name of an instance, the static member name of a class, or the variable name
+// of a module.
+func (inter *Interpreter) getProperty(obj object.Object, name *lexer.Token) (object.Object, error) {
+	if obj.Type() == object.Module {
+		return obj.(*Module).Get(name)
+	}
+
+	if obj.Type() == object.Class {
+		return obj.(*Class).GetStatic(name)
+	}
+
 	if obj.Type() != object.Instance {
-		return nil, object.NewRuntimeError(expr.Name, "Only instances have properties.")
+		return nil, object.NewRuntimeError(name, "Only instances have properties.")
 	}
 
 	inst := obj.(*Instance)
-	return inst.Get(expr.Name)
+	return inst.Get(inter, name)
 }
 
 func (inter *Interpreter) VisitGroupingExpr(expr *object.GroupingExpr) (object.Object, error) {
@@ -588,28 +1296,65 @@ func (inter *Interpreter) VisitIndexExpr(expr *object.IndexExpr) (object.Object,
 		return nil, err
 	}
 
-	if left.Type() != object.List {
-		return nil, object.NewRuntimeError(expr.Operator, "Cannot perform index lookup on anything except a list.")
+	return inter.index(expr.Operator, left, right)
+}
+
+// index returns the element right of left, which is a list, a string or an instance defining __index.
+func (inter *Interpreter) index(oper *lexer.Token, left, right object.Object) (object.Object, error) {
+	if method := specialMethod(left, "__index"); method != nil {
+		return inter.callSpecial(oper, method, right)
+	}
+
+	if left.Type() != object.List && left.Type() != object.String {
+		return nil, object.NewRuntimeError(oper, "Cannot perform index lookup on anything except a list or string.")
 	}
 
 	if right.Type() != object.Number {
-		return nil, object.NewRuntimeError(expr.Operator, "Index operand must be a number.")
+		return nil, object.NewRuntimeError(oper, "Index operand must be a number.")
 	}
 
-	l := left.(*List)
 	r := right.(*Number)
 	var ind int
-	if r.IsInt {
+	if r.Big != nil {
+		ind = -1
+	} else if r.IsInt {
 		ind = r.Int
 	} else {
 		ind = int(r.Float)
 	}
 
-	if ind >= len(l.Elements) {
-		return nil, object.NewRuntimeError(expr.Operator, "Index out of range.")
+	// Strings are indexed by character rather than by byte.
+	if left.Type() == object.String {
+		runes := []rune(left.(*String).Value)
+		if ind < 0 || ind >= len(runes) {
+			return nil, object.NewRuntimeError(oper, "Index out of range.")
+		}
+		return &String{Value: string(runes[ind])}, nil
+	}
+
+	l := left.(*List)
+	if ind < 0 || ind >= len(l.Elements) {
+		return nil, object.NewRuntimeError(oper, "Index out of range.")
 	}
 	return l.Elements[ind], nil
+}
 
+func (inter *Interpreter) VisitInterpolationExpr(expr *object.InterpolationExpr) (object.Object, error) {
+	var out bytes.Buffer
+
+	for _, part := range expr.Parts {
+		value, err := inter.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		s, err := inter.stringify(expr.Token, value)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(s)
+	}
+
+	return &String{Value: out.String()}, nil
 }
 
 func (inter *Interpreter) VisitListExpr(expr *object.ListExpr) (object.Object, error) {
@@ -632,7 +1377,11 @@ func (inter *Interpreter) VisitLogicalExpr(expr *object.LogicalExpr) (object.Obj
 	if err != nil {
 		return nil, err
 	}
-	if expr.Operator.Type == lexer.Or {
+	if expr.Operator.Type == lexer.QuestionQuestion {
+		if left.Type() != object.Null {
+			return left, nil
+		}
+	} else if expr.Operator.Type == lexer.Or {
 		if isTruthy(left) {
 			return left, nil
 		}
@@ -650,6 +1399,7 @@ func (inter *Interpreter) VisitNumberExpr(expr *object.NumberExpr) (object.Objec
 	if expr.Token.Type == lexer.NumberI {
 		n.IsInt = true
 		n.Int = expr.Int
+		n.Big = expr.Big
 	} else {
 		n.Float = expr.Float
 	}
@@ -661,65 +1411,108 @@ func (inter *Interpreter) VisitNullExpr(expr *object.NullExpr) (object.Object, e
 	return NullOb, nil
 }
 
+// VisitOptionalExpr evaluates a chain containing ?., which is null if the chain was skipped.
+func (inter *Interpreter) VisitOptionalExpr(expr *object.OptionalExpr) (object.Object, error) {
+	value, err := inter.evaluate(expr.Expression)
+	if err == skipChain {
+		return NullOb, nil
+	}
+	return value, err
+}
+
 func (inter *Interpreter) VisitSetExpr(expr *object.SetExpr) (object.Object, error) {
+	return inter.set(expr, func() (object.Object, error) { return inter.evaluate(expr.Value) })
+}
+
+// set stores the result of value in the property or element expr refers to. value is only called once the target
+// has been evaluated and checked.
+func (inter *Interpreter) set(expr *object.SetExpr, value func() (object.Object, error)) (object.Object, error) {
 	if expr.IsIndex {
-		return inter.setIndexValue(expr)
+		ie := expr.Object.(*object.IndexExpr)
+		obj, err := inter.evaluate(ie.Left)
+		if err != nil {
+			return nil, err
+		}
+		key, err := inter.evaluate(ie.Right)
+		if err != nil {
+			return nil, err
+		}
+		return inter.setIndex(ie.Operator, obj, key, value)
 	}
 
 	obj, err := inter.evaluate(expr.Object)
 	if err != nil {
 		return nil, err
 	}
+	return inter.setProperty(obj, expr.Name, value)
+}
 
-	if obj.Type() != object.Instance {
-		return nil, object.NewRuntimeError(expr.Name, "Only instances have fields.")
+// setProperty stores the result of value in the field name of an instance or the static field name of a class.
+func (inter *Interpreter) setProperty(obj object.Object, name *lexer.Token, value func() (object.Object, error)) (object.Object, error) {
+	if obj.Type() != object.Instance && obj.Type() != object.Class {
+		return nil, object.NewRuntimeError(name, "Only instances have fields.")
 	}
 
-	inst := obj.(*Instance)
-	value, err := inter.evaluate(expr.Value)
+	val, err := value()
 	if err != nil {
 		return nil, err
 	}
-	inst.Set(expr.Name, value)
-	return value, nil
-}
 
-func (inter *Interpreter) setIndexValue(expr *object.SetExpr) (object.Object, error) {
-	ie := expr.Object.(*object.IndexExpr)
-	li, err := inter.evaluate(ie.Left)
+	if klass, ok := obj.(*Class); ok {
+		err = klass.SetStatic(inter, name, val)
+	} else {
+		err = obj.(*Instance).Set(inter, name, val)
+	}
 	if err != nil {
-		return nil, err
+		return nil, callError(name, err)
 	}
-	if li.Type() != object.List {
-		return nil, object.NewRuntimeError(ie.Operator, "Cannot perform index lookup on anything except a list.")
+	return val, nil
+}
+
+// setIndex stores the result of value in the element key of obj, which is a list or an instance defining
+// __setindex.
+func (inter *Interpreter) setIndex(oper *lexer.Token, obj, key object.Object, value func() (object.Object, error)) (object.Object, error) {
+	if method := specialMethod(obj, "__setindex"); method != nil {
+		val, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err = inter.callSpecial(oper, method, key, val); err != nil {
+			return nil, err
+		}
+		return val, nil
 	}
-	list := li.(*List)
-	ind, err := inter.evaluate(ie.Right)
-	if err != nil {
-		return nil, err
+	if obj.Type() != object.List {
+		return nil, object.NewRuntimeError(oper, "Cannot perform index lookup on anything except a list.")
 	}
-	if ind.Type() != object.Number {
-		return nil, object.NewRuntimeError(ie.Operator, "Operand must be a number.")
+	list := obj.(*List)
+	if list.frozen {
+		return nil, object.NewRuntimeError(oper, "Cannot modify a frozen list.")
 	}
-	in := ind.(*Number)
+	if key.Type() != object.Number {
+		return nil, object.NewRuntimeError(oper, "Operand must be a number.")
+	}
+	in := key.(*Number)
 	var index int
 
-	if in.IsInt {
+	if in.Big != nil {
+		index = -1
+	} else if in.IsInt {
 		index = in.Int
 	} else {
 		index = int(in.Float)
 	}
 
-	if index >= len(list.Elements) {
-		return nil, object.NewRuntimeError(ie.Operator, "Index out of range.")
+	if index < 0 || index >= len(list.Elements) {
+		return nil, object.NewRuntimeError(oper, "Index out of range.")
 	}
-	value, err := inter.evaluate(expr.Value)
+	val, err := value()
 	if err != nil {
 		return nil, err
 	}
 
-	list.Elements[index] = value
-	return value, nil
+	list.Elements[index] = val
+	return val, nil
 }
 
 func (inter *Interpreter) VisitStringExpr(expr *object.StringExpr) (object.Object, error) {
@@ -736,6 +1529,11 @@ func (inter *Interpreter) VisitSuperExpr(expr *object.SuperExpr) (object.Object,
 		return nil, err
 	}
 
+	// A trait mixed in last, into a class without a superclass, has nothing after it.
+	if sc.Type() == object.Null {
+		return nil, object.NewRuntimeError(expr.Method, "Undefined property on super.")
+	}
+
 	if sc.Type() != object.Class {
 		return nil, object.NewRuntimeError(expr.Keyword, "Superclass was not a Class.")
 	}
@@ -747,6 +1545,11 @@ func (inter *Interpreter) VisitSuperExpr(expr *object.SuperExpr) (object.Object,
 		return nil, err
 	}
 
+	// In a static method this is the class, and super refers to the static methods of the superclass.
+	if o.Type() == object.Class {
+		return superClass.getStatic(o.(*Class), expr.Method)
+	}
+
 	if o.Type() != object.Instance {
 		return nil, object.NewRuntimeError(expr.Keyword, "this was not an Instance of a class.")
 	}
@@ -771,16 +1574,29 @@ func (inter *Interpreter) VisitUnaryExpr(expr *object.UnaryExpr) (object.Object,
 
 	switch expr.Operator.Type {
 	case lexer.Minus:
+		if method := specialMethod(right, "__neg"); method != nil {
+			return inter.callSpecial(expr.Operator, method)
+		}
 		if right.Type() != object.Number {
 			return nil, object.NewRuntimeError(expr.Operator, "Operand must be a number.")
 		}
 		r := right.(*Number)
 		if r.IsInt {
-			r.Int = -r.Int
-		} else {
-			r.Float = -r.Float
+			if r.Big != nil || r.Int == math.MinInt {
+				return newBigNumber(new(big.Int).Neg(r.bigInt())), nil
+			}
+			return &Number{IsInt: true, Int: -r.Int}, nil
+		}
+		return &Number{Float: -r.Float}, nil
+	case lexer.Tild:
+		r, ok := right.(*Number)
+		if !ok || !r.IsInt {
+			return nil, object.NewRuntimeError(expr.Operator, "Operand must be an integer.")
+		}
+		if r.Big != nil {
+			return newBigNumber(new(big.Int).Not(r.Big)), nil
 		}
-		return r, nil
+		return &Number{IsInt: true, Int: ^r.Int}, nil
 	case lexer.Bang:
 		b := !isTruthy(right)
 		if b {
@@ -793,6 +1609,82 @@ func (inter *Interpreter) VisitUnaryExpr(expr *object.UnaryExpr) (object.Object,
 	return nil, nil
 }
 
+// VisitUpdateExpr applies ++ or --. A prefix update gives the new value, and a postfix update the value before it.
+// The object and index of the target are evaluated once, and its value is read and stored through them.
+func (inter *Interpreter) VisitUpdateExpr(expr *object.UpdateExpr) (object.Object, error) {
+	var step *object.BinaryExpr
+	var old object.Object
+	var store func(value func() (object.Object, error)) (object.Object, error)
+	var err error
+
+	switch assign := expr.Assign.(type) {
+	case *object.AssignExpr:
+		step = assign.Value.(*object.BinaryExpr)
+		old, err = inter.evaluate(expr.Target)
+		store = func(value func() (object.Object, error)) (object.Object, error) {
+			val, err := value()
+			if err != nil {
+				return nil, err
+			}
+			return val, inter.assignVariable(assign, val)
+		}
+	case *object.SetExpr:
+		step = assign.Value.(*object.BinaryExpr)
+		old, store, err = inter.updateTarget(assign)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Instances may still be updated through __add and __sub.
+	if old.Type() != object.Number && old.Type() != object.Instance {
+		return nil, object.NewRuntimeError(expr.Operator, "Operand of "+expr.Operator.Lexeme+" must be a number.")
+	}
+
+	one, err := inter.evaluate(step.Right)
+	if err != nil {
+		return nil, err
+	}
+	value, err := store(func() (object.Object, error) { return inter.binary(step.Operator, old, one) })
+	if err != nil {
+		return nil, err
+	}
+	if expr.Prefix {
+		return value, nil
+	}
+	return old, nil
+}
+
+// updateTarget evaluates the object, and index, of the property or element assigned by expr. It returns the value
+// held there and a function storing a new value in the same place.
+func (inter *Interpreter) updateTarget(expr *object.SetExpr) (object.Object, func(func() (object.Object, error)) (object.Object, error), error) {
+	if expr.IsIndex {
+		ie := expr.Object.(*object.IndexExpr)
+		obj, err := inter.evaluate(ie.Left)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := inter.evaluate(ie.Right)
+		if err != nil {
+			return nil, nil, err
+		}
+		old, err := inter.index(ie.Operator, obj, key)
+		store := func(value func() (object.Object, error)) (object.Object, error) {
+			return inter.setIndex(ie.Operator, obj, key, value)
+		}
+		return old, store, err
+	}
+
+	obj, err := inter.evaluate(expr.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, err := inter.getProperty(obj, expr.Name)
+	store := func(value func() (object.Object, error)) (object.Object, error) {
+		return inter.setProperty(obj, expr.Name, value)
+	}
+	return old, store, err
+}
+
 func (inter *Interpreter) VisitVariableExpr(expr *object.VariableExpr) (object.Object, error) {
 	return inter.lookupVariable(expr.Name, expr)
 }
@@ -0,0 +1,265 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// jsonObject is the class of the instances jsonDecode returns for JSON objects, which have a field for each key.
+var jsonObject = &Class{Name: "Object"}
+
+// jsonEncoder writes a value as JSON. Integers are written without a decimal point and floats always with one or an
+// exponent, so that each decodes back to the same kind of number.
+type jsonEncoder struct {
+	buf    bytes.Buffer
+	indent string
+	seen   map[object.Object]bool // The lists and instances being encoded, to detect cycles.
+}
+
+func (e *jsonEncoder) encode(value object.Object, depth int) error {
+	switch v := value.(type) {
+	case *Null:
+		e.buf.WriteString("null")
+	case *Boolean:
+		e.buf.WriteString(v.String())
+	case *Number:
+		if !v.IsInt && (math.IsInf(v.Float, 0) || math.IsNaN(v.Float)) {
+			return BIError("'jsonEncode' cannot encode " + v.String() + ".")
+		}
+		e.buf.WriteString(v.String())
+	case *String:
+		e.writeString(v.Value)
+	case *List:
+		if e.seen[v] {
+			return BIError("'jsonEncode' cannot encode a list which contains itself.")
+		}
+		e.seen[v] = true
+		defer delete(e.seen, v)
+
+		e.buf.WriteByte('[')
+		for i, el := range v.Elements {
+			e.separate(i, depth+1)
+			if err := e.encode(el, depth+1); err != nil {
+				return err
+			}
+		}
+		e.close(len(v.Elements), depth, ']')
+	case *Instance:
+		if e.seen[v] {
+			return BIError("'jsonEncode' cannot encode an instance which contains itself.")
+		}
+		e.seen[v] = true
+		defer delete(e.seen, v)
+
+		names := make([]string, 0, len(v.fields))
+		for name := range v.fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		e.buf.WriteByte('{')
+		for i, name := range names {
+			e.separate(i, depth+1)
+			e.writeString(name)
+			e.buf.WriteByte(':')
+			if e.indent != "" {
+				e.buf.WriteByte(' ')
+			}
+			if err := e.encode(v.fields[name], depth+1); err != nil {
+				return err
+			}
+		}
+		e.close(len(names), depth, '}')
+	default:
+		return BIError("'jsonEncode' cannot encode a value of type " + value.Type().String() + ".")
+	}
+	return nil
+}
+
+// separate writes what comes before the element i of a list or object at depth.
+func (e *jsonEncoder) separate(i int, depth int) {
+	if i > 0 {
+		e.buf.WriteByte(',')
+	}
+	e.newline(depth)
+}
+
+// close ends a list or object at depth holding n elements.
+func (e *jsonEncoder) close(n int, depth int, end byte) {
+	if n > 0 {
+		e.newline(depth)
+	}
+	e.buf.WriteByte(end)
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+	e.buf.WriteByte('\n')
+	e.buf.WriteString(strings.Repeat(e.indent, depth))
+}
+
+// writeString writes s as a quoted JSON string, escaping only what JSON requires. Invalid UTF-8 is replaced.
+func (e *jsonEncoder) writeString(s string) {
+	e.buf.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			e.buf.WriteByte('\\')
+			e.buf.WriteRune(r)
+		case r == '\n':
+			e.buf.WriteString(`\n`)
+		case r == '\r':
+			e.buf.WriteString(`\r`)
+		case r == '\t':
+			e.buf.WriteString(`\t`)
+		case r < 0x20 || r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&e.buf, `\u%04x`, r)
+		default:
+			e.buf.WriteRune(r)
+		}
+	}
+	e.buf.WriteByte('"')
+}
+
+// bJSONEncode returns a value as a JSON string. Lists become arrays and instances objects of their fields, in
+// sorted order. The optional indent, a number of spaces or a string, puts each element on a line of its own.
+func bJSONEncode(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'jsonEncode' expects a value and an optional indent.")
+	}
+
+	e := &jsonEncoder{seen: make(map[object.Object]bool)}
+	if len(args) == 2 {
+		switch indent := args[1].(type) {
+		case *Number:
+			if !indent.IsInt || indent.Big != nil || indent.Int < 0 {
+				return NullOb, BIError("'jsonEncode' indent must be a non-negative integer or a string.")
+			}
+			e.indent = strings.Repeat(" ", indent.Int)
+		case *String:
+			e.indent = indent.Value
+		default:
+			return NullOb, BIError("'jsonEncode' indent must be a non-negative integer or a string.")
+		}
+	}
+
+	if err := e.encode(args[0], 0); err != nil {
+		return NullOb, err
+	}
+	return &String{Value: e.buf.String()}, nil
+}
+
+// bJSONDecode parses a JSON string. Arrays become lists and objects instances with a field for each key. Numbers
+// written with a decimal point or an exponent become floats, and the rest integers. Malformed JSON fails with the
+// line and column where it went wrong.
+func bJSONDecode(interp *Interpreter, args []object.Object) (object.Object, error) {
+	str, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'jsonDecode' argument must be of a type STRING.")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(str.Value))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return NullOb, jsonError(str.Value, err)
+	}
+
+	rest := str.Value[dec.InputOffset():]
+	if trailing := strings.TrimLeft(rest, " \t\r\n"); trailing != "" {
+		at := len(str.Value) - len(trailing)
+		return NullOb, jsonErrorAt(str.Value, "unexpected data after the value", at)
+	}
+
+	return jsonValue(v)
+}
+
+// jsonError describes err, a failure to decode input.
+func jsonError(input string, err error) error {
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		// The offset is just past the character which could not be decoded.
+		return jsonErrorAt(input, syntax.Error(), int(syntax.Offset)-1)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return jsonErrorAt(input, "unexpected end of input", len(input))
+	}
+	return BIError("'jsonDecode' " + err.Error() + ".")
+}
+
+// jsonErrorAt describes a failure to decode input at the byte offset at, by its line and column.
+func jsonErrorAt(input string, msg string, at int) error {
+	before := input[:at]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+	return BIError(fmt.Sprintf("'jsonDecode' %s at line %d, column %d.", msg, line, column))
+}
+
+// jsonValue converts a value decoded by encoding/json to an object.
+func jsonValue(v interface{}) (object.Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return NullOb, nil
+	case bool:
+		if v {
+			return True, nil
+		}
+		return False, nil
+	case string:
+		return &String{Value: v}, nil
+	case json.Number:
+		return jsonNumber(string(v))
+	case []interface{}:
+		l := &List{Elements: make([]object.Object, len(v))}
+		for i, el := range v {
+			value, err := jsonValue(el)
+			if err != nil {
+				return nil, err
+			}
+			l.Elements[i] = value
+		}
+		return l, nil
+	case map[string]interface{}:
+		inst := &Instance{klass: jsonObject, fields: make(map[string]object.Object, len(v))}
+		for name, field := range v {
+			value, err := jsonValue(field)
+			if err != nil {
+				return nil, err
+			}
+			inst.fields[name] = value
+		}
+		return inst, nil
+	}
+	return nil, BIError(fmt.Sprintf("'jsonDecode' unexpected value %v.", v))
+}
+
+// jsonNumber parses a JSON number, as an integer unless it has a decimal point or an exponent.
+func jsonNumber(s string) (object.Object, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		if n, err := strconv.Atoi(s); err == nil {
+			return &Number{IsInt: true, Int: n}, nil
+		}
+		b, _ := new(big.Int).SetString(s, 10)
+		return newBigNumber(b), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, BIError("'jsonDecode' number " + s + " is out of range.")
+	}
+	return &Number{Float: f}, nil
+}
@@ -0,0 +1,308 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// newMathModule returns the math module. Functions which do not change the kind of their argument, such as abs,
+// min and clamp, return integers for integer input; floor, ceil, round and toInt always return integers; and the
+// remaining functions return floats.
+func newMathModule() *Module {
+	return newModule("math", map[string]object.Object{
+		"PI": &Number{Float: math.Pi},
+		"E":  &Number{Float: math.E},
+
+		"abs":     newBuiltin(1, bMathAbs),
+		"min":     newBuiltin(-1, bMathMin),
+		"max":     newBuiltin(-1, bMathMax),
+		"clamp":   newBuiltin(3, bMathClamp),
+		"floor":   newBuiltin(1, mathRounding("floor", math.Floor)),
+		"ceil":    newBuiltin(1, mathRounding("ceil", math.Ceil)),
+		"round":   newBuiltin(1, mathRounding("round", math.Round)),
+		"sqrt":    newBuiltin(1, bMathSqrt),
+		"pow":     newBuiltin(2, bMathPow),
+		"log":     newBuiltin(-1, bMathLog),
+		"sin":     newBuiltin(1, mathFloatFn("sin", math.Sin)),
+		"cos":     newBuiltin(1, mathFloatFn("cos", math.Cos)),
+		"tan":     newBuiltin(1, mathFloatFn("tan", math.Tan)),
+		"asin":    newBuiltin(1, mathInverseTrig("asin", math.Asin)),
+		"acos":    newBuiltin(1, mathInverseTrig("acos", math.Acos)),
+		"atan":    newBuiltin(1, mathFloatFn("atan", math.Atan)),
+		"atan2":   newBuiltin(2, bMathAtan2),
+		"toInt":   newBuiltin(1, bMathToInt),
+		"toFloat": newBuiltin(1, bMathToFloat),
+	})
+}
+
+// numberArgs checks that every argument passed to the builtin name is a number.
+func numberArgs(name string, args []object.Object) ([]*Number, error) {
+	nums := make([]*Number, len(args))
+	for i, arg := range args {
+		n, ok := arg.(*Number)
+		if !ok {
+			return nil, BIError("'" + name + "' arguments must be numbers.")
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// numberLess reports whether l is less than r, comparing integers exactly.
+func numberLess(l, r *Number) bool {
+	if l.IsInt && r.IsInt {
+		if l.Big != nil || r.Big != nil {
+			return l.bigInt().Cmp(r.bigInt()) < 0
+		}
+		return l.Int < r.Int
+	}
+	return l.float() < r.float()
+}
+
+func bMathAbs(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("math.abs", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	n := nums[0]
+	if !n.IsInt {
+		return &Number{Float: math.Abs(n.Float)}, nil
+	}
+	if n.Big == nil && n.Int >= 0 {
+		return n, nil
+	}
+	return newBigNumber(new(big.Int).Abs(n.bigInt())), nil
+}
+
+// mathExtreme returns the smallest (or largest) of the arguments, or of the elements of a single list argument.
+func mathExtreme(name string, args []object.Object, largest bool) (object.Object, error) {
+	if len(args) == 1 && args[0].Type() == object.List {
+		args = args[0].(*List).Elements
+	}
+	if len(args) == 0 {
+		return NullOb, BIError("'" + name + "' expects at least one number.")
+	}
+
+	nums, err := numberArgs(name, args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	result := nums[0]
+	for _, n := range nums[1:] {
+		if largest && numberLess(result, n) || !largest && numberLess(n, result) {
+			result = n
+		}
+	}
+	return result, nil
+}
+
+func bMathMin(interp *Interpreter, args []object.Object) (object.Object, error) {
+	return mathExtreme("math.min", args, false)
+}
+
+func bMathMax(interp *Interpreter, args []object.Object) (object.Object, error) {
+	return mathExtreme("math.max", args, true)
+}
+
+// bMathClamp limits a value to the range low to high inclusive.
+func bMathClamp(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("math.clamp", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	value, low, high := nums[0], nums[1], nums[2]
+	if numberLess(high, low) {
+		return NullOb, BIError("'math.clamp' low must not be greater than high.")
+	}
+
+	if numberLess(value, low) {
+		return low, nil
+	}
+	if numberLess(high, value) {
+		return high, nil
+	}
+	return value, nil
+}
+
+// mathRounding returns a builtin which rounds a float to an integer with fn. Integers are returned unchanged.
+func mathRounding(name string, fn func(float64) float64) CallFn {
+	name = "math." + name
+	return func(interp *Interpreter, args []object.Object) (object.Object, error) {
+		nums, err := numberArgs(name, args)
+		if err != nil {
+			return NullOb, err
+		}
+
+		if nums[0].IsInt {
+			return nums[0], nil
+		}
+
+		n, err := floatToInt(fn(nums[0].Float))
+		if err != nil {
+			return NullOb, BIError("'" + name + "' argument must be a finite number.")
+		}
+		return n, nil
+	}
+}
+
+// mathFloatFn returns a builtin which applies fn to a single number and returns a float.
+func mathFloatFn(name string, fn func(float64) float64) CallFn {
+	name = "math." + name
+	return func(interp *Interpreter, args []object.Object) (object.Object, error) {
+		nums, err := numberArgs(name, args)
+		if err != nil {
+			return NullOb, err
+		}
+
+		return &Number{Float: fn(nums[0].float())}, nil
+	}
+}
+
+// mathInverseTrig returns a builtin which applies fn, an inverse of sin or cos, to a number from -1 to 1.
+func mathInverseTrig(name string, fn func(float64) float64) CallFn {
+	name = "math." + name
+	return func(interp *Interpreter, args []object.Object) (object.Object, error) {
+		nums, err := numberArgs(name, args)
+		if err != nil {
+			return NullOb, err
+		}
+
+		f := nums[0].float()
+		if f < -1 || f > 1 {
+			return NullOb, BIError("'" + name + "' argument must be from -1 to 1.")
+		}
+		return &Number{Float: fn(f)}, nil
+	}
+}
+
+func bMathSqrt(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("math.sqrt", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	f := nums[0].float()
+	if f < 0 {
+		return NullOb, BIError("'math.sqrt' argument must not be negative.")
+	}
+	return &Number{Float: math.Sqrt(f)}, nil
+}
+
+// bMathPow raises base to exponent. An integer raised to a non-negative integer is calculated exactly, as long as the
+// result is not too large.
+func bMathPow(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("math.pow", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	base, exp := nums[0], nums[1]
+	if base.IsInt && exp.IsInt && exp.bigInt().Sign() >= 0 {
+		n, err := intPow(base.bigInt(), exp.bigInt())
+		if err != nil {
+			return NullOb, BIError(err.Error())
+		}
+		return n, nil
+	}
+
+	if base.float() == 0 && exp.float() < 0 {
+		return NullOb, BIError(DivisionByZero.Error())
+	}
+	if base.float() < 0 && !exp.IsInt && exp.Float != math.Trunc(exp.Float) {
+		return NullOb, BIError("'math.pow' cannot raise a negative number to a fractional power.")
+	}
+	return &Number{Float: math.Pow(base.float(), exp.float())}, nil
+}
+
+// bMathLog returns the natural logarithm of a number, or its logarithm in the base given as a second argument.
+func bMathLog(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'math.log' expects a number and an optional base.")
+	}
+
+	nums, err := numberArgs("math.log", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	x := nums[0].float()
+	if x <= 0 {
+		return NullOb, BIError("'math.log' argument must be greater than zero.")
+	}
+
+	if len(nums) == 1 {
+		return &Number{Float: math.Log(x)}, nil
+	}
+
+	base := nums[1].float()
+	if base <= 0 || base == 1 {
+		return NullOb, BIError("'math.log' base must be greater than zero and not one.")
+	}
+	switch base {
+	case 2:
+		return &Number{Float: math.Log2(x)}, nil
+	case 10:
+		return &Number{Float: math.Log10(x)}, nil
+	}
+	return &Number{Float: math.Log(x) / math.Log(base)}, nil
+}
+
+func bMathAtan2(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("math.atan2", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	return &Number{Float: math.Atan2(nums[0].float(), nums[1].float())}, nil
+}
+
+// bMathToInt converts a number to an integer, truncating toward zero, or parses a string holding an integer.
+func bMathToInt(interp *Interpreter, args []object.Object) (object.Object, error) {
+	switch obj := args[0]; obj.Type() {
+	case object.Number:
+		n := obj.(*Number)
+		if n.IsInt {
+			return n, nil
+		}
+		i, err := floatToInt(n.Float)
+		if err != nil {
+			return NullOb, BIError("'math.toInt' argument must be a finite number.")
+		}
+		return i, nil
+	case object.String:
+		s := strings.TrimSpace(obj.(*String).Value)
+		if i, err := strconv.Atoi(s); err == nil {
+			return &Number{IsInt: true, Int: i}, nil
+		}
+		if b, ok := new(big.Int).SetString(s, 10); ok {
+			return newBigNumber(b), nil
+		}
+		return NullOb, BIError("'math.toInt' could not parse " + strconv.Quote(s) + " as an integer.")
+	}
+
+	return NullOb, BIError("'math.toInt' argument must be of a type NUMBER or STRING.")
+}
+
+// bMathToFloat converts a number to a float, or parses a string holding a number.
+func bMathToFloat(interp *Interpreter, args []object.Object) (object.Object, error) {
+	switch obj := args[0]; obj.Type() {
+	case object.Number:
+		return &Number{Float: obj.(*Number).float()}, nil
+	case object.String:
+		s := strings.TrimSpace(obj.(*String).Value)
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return NullOb, BIError("'math.toFloat' could not parse " + strconv.Quote(s) + " as a number.")
+		}
+		return &Number{Float: f}, nil
+	}
+
+	return NullOb, BIError("'math.toFloat' argument must be of a type NUMBER or STRING.")
+}
@@ -0,0 +1,28 @@
+package interpreter
+
+import (
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
+
+// Module is a named group of builtin functions and constants, such as math, whose members are looked up with a
+// get expression: math.sqrt(2).
+type Module struct {
+	Name    string
+	members map[string]object.Object
+}
+
+func newModule(name string, members map[string]object.Object) *Module {
+	return &Module{Name: name, members: members}
+}
+
+func (m *Module) Type() object.Type { return object.Module }
+func (m *Module) String() string    { return "<module " + m.Name + ">" }
+
+func (m *Module) Get(name *lexer.Token) (object.Object, error) {
+	if v, ok := m.members[name.Lexeme]; ok {
+		return v, nil
+	}
+
+	return nil, object.NewRuntimeError(name, "Undefined property on module "+m.Name+".")
+}
@@ -2,8 +2,13 @@ package interpreter
 
 import "bytes"
 import (
+	"errors"
 	"fmt"
 	"github.com/butlermatt/glpc/object"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
 )
 
 type Null struct{}
@@ -25,6 +30,7 @@ func (b *Boolean) String() string {
 
 type List struct {
 	Elements []object.Object
+	frozen   bool // Set by freeze, after which the list cannot be modified.
 }
 
 func (l *List) Type() object.Type { return object.List }
@@ -51,18 +57,85 @@ func (l *List) String() string {
 	return out.String()
 }
 
+// Number is an integer or floating point value. Integers which do not fit in an int are held in Big, in which case
+// Int is unused.
 type Number struct {
 	IsInt bool
 	Int   int
 	Float float64
+	Big   *big.Int
+}
+
+// newBigNumber returns an integer Number holding b, using Int when the value fits.
+func newBigNumber(b *big.Int) *Number {
+	if b.IsInt64() && int64(int(b.Int64())) == b.Int64() {
+		return &Number{IsInt: true, Int: int(b.Int64())}
+	}
+	return &Number{IsInt: true, Big: b}
+}
+
+// floatToInt returns an integer Number holding f truncated toward zero. f must be finite.
+func floatToInt(f float64) (*Number, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errors.New("Result is not a finite number.")
+	}
+
+	if f >= math.MinInt && f < math.MaxInt {
+		return &Number{IsInt: true, Int: int(f)}, nil
+	}
+
+	b, _ := big.NewFloat(f).Int(nil)
+	return newBigNumber(b), nil
+}
+
+// bigInt returns the value of an integer Number as a big.Int. The result must not be modified.
+func (n *Number) bigInt() *big.Int {
+	if n.Big != nil {
+		return n.Big
+	}
+	return big.NewInt(int64(n.Int))
+}
+
+// float returns the value of the Number as a float64, converting integers when required.
+func (n *Number) float() float64 {
+	if !n.IsInt {
+		return n.Float
+	}
+	if n.Big != nil {
+		f, _ := new(big.Float).SetInt(n.Big).Float64()
+		return f
+	}
+	return float64(n.Int)
 }
 
 func (n *Number) Type() object.Type { return object.Number }
 func (n *Number) String() string {
+	if n.Big != nil {
+		return n.Big.String()
+	}
 	if n.IsInt {
 		return fmt.Sprintf("%d", n.Int)
 	}
-	return fmt.Sprintf("%.2f", n.Float)
+	return formatFloat(n.Float)
+}
+
+// formatFloat returns the shortest representation of f which parses back to the same value. Whole numbers keep a
+// trailing ".0" so they cannot be mistaken for integers, and very large or small magnitudes use exponent notation.
+func formatFloat(f float64) string {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(str, ".") {
+		str += ".0"
+	}
+	return str
 }
 
 type String struct {
@@ -0,0 +1,175 @@
+package interpreter
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
+
+// binaryMethods maps each overloadable binary operator to the special method which implements it when the left
+// operand is an instance.
+var binaryMethods = map[lexer.TokenType]string{
+	lexer.Plus:           "__add",
+	lexer.Minus:          "__sub",
+	lexer.Star:           "__mul",
+	lexer.StarStar:       "__pow",
+	lexer.Slash:          "__div",
+	lexer.TildSlash:      "__idiv",
+	lexer.Percent:        "__mod",
+	lexer.Amp:            "__and",
+	lexer.Pipe:           "__or",
+	lexer.Caret:          "__xor",
+	lexer.LessLess:       "__shl",
+	lexer.GreaterGreater: "__shr",
+	lexer.Less:           "__lt",
+	lexer.LessEq:         "__le",
+	lexer.Greater:        "__gt",
+	lexer.GreaterEq:      "__ge",
+	lexer.EqualEq:        "__eq",
+	lexer.BangEq:         "__eq",
+}
+
+// reflectedMethods maps the comparison operators to the special method of the right operand which gives the same
+// result when only the right operand is an instance. a < b is answered by b.__gt(a).
+var reflectedMethods = map[lexer.TokenType]string{
+	lexer.Less:      "__gt",
+	lexer.LessEq:    "__ge",
+	lexer.Greater:   "__lt",
+	lexer.GreaterEq: "__le",
+	lexer.EqualEq:   "__eq",
+	lexer.BangEq:    "__eq",
+}
+
+// protocolMethods names the ordinary methods which are used in place of a special method a class does not define.
+var protocolMethods = map[string]string{
+	"__eq":  "equals",
+	"__str": "toString",
+}
+
+// specialMethod returns the method name defined by the class of obj, bound to obj. It returns nil if obj is not an
+// instance or its class defines neither the method nor its protocol method.
+func specialMethod(obj object.Object, name string) *Function {
+	inst, ok := obj.(*Instance)
+	if !ok {
+		return nil
+	}
+
+	if m := inst.klass.findMethod(inst, name); m != nil {
+		return m
+	}
+	if alt, ok := protocolMethods[name]; ok {
+		return inst.klass.findMethod(inst, alt)
+	}
+	return nil
+}
+
+// callSpecial calls a special method with args. Errors are reported at tok, or left for the calling builtin's call
+// expression to locate when tok is nil.
+func (inter *Interpreter) callSpecial(tok *lexer.Token, method *Function, args ...object.Object) (object.Object, error) {
+	if min, max := arityRange(method); len(args) < min || max != -1 && len(args) > max {
+		msg := fmt.Sprintf("Method %s must take %d arguments but takes %d.", method.declaration.Name.Lexeme, len(args), method.Arity())
+		return nil, callError(tok, BIError(msg))
+	}
+
+	value, err := method.Call(inter, args)
+	if err != nil {
+		return nil, callError(tok, err)
+	}
+	return value, nil
+}
+
+// binaryOverload applies a binary operator through the special methods of its operands. It returns false if
+// neither operand defines a method for the operator. Comparison results are converted to booleans, and != is the
+// negation of __eq.
+func (inter *Interpreter) binaryOverload(oper *lexer.Token, left, right object.Object) (object.Object, bool, error) {
+	var method *Function
+	var arg object.Object
+
+	if name, ok := binaryMethods[oper.Type]; ok {
+		method, arg = specialMethod(left, name), right
+	}
+	if name, ok := reflectedMethods[oper.Type]; ok && method == nil {
+		method, arg = specialMethod(right, name), left
+	}
+	if method == nil {
+		return nil, false, nil
+	}
+
+	value, err := inter.callSpecial(oper, method, arg)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if _, ok := reflectedMethods[oper.Type]; !ok {
+		return value, true, nil
+	}
+
+	if isTruthy(value) != (oper.Type == lexer.BangEq) {
+		return True, true, nil
+	}
+	return False, true, nil
+}
+
+// equal reports whether two values are equal. When either is an instance which defines __eq or equals the method
+// decides, otherwise values are compared with isEqual.
+func (inter *Interpreter) equal(tok *lexer.Token, left, right object.Object) (bool, error) {
+	method, arg := specialMethod(left, "__eq"), right
+	if method == nil {
+		method, arg = specialMethod(right, "__eq"), left
+	}
+
+	if method != nil {
+		value, err := inter.callSpecial(tok, method, arg)
+		if err != nil {
+			return false, err
+		}
+		return isTruthy(value), nil
+	}
+
+	b, err := isEqual(lexer.NewToken(lexer.EqualEq, "==", "", 0), left, right)
+	if err != nil {
+		return false, err
+	}
+	return b.Value, nil
+}
+
+// stringify returns the text printed for a value. Instances which define __str or toString are printed with its
+// result, which must be a string, including when they are held in a list.
+func (inter *Interpreter) stringify(tok *lexer.Token, obj object.Object) (string, error) {
+	switch obj := obj.(type) {
+	case *List:
+		var out bytes.Buffer
+		out.WriteByte('[')
+		for i, el := range obj.Elements {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			s, err := inter.stringify(tok, el)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(s)
+		}
+		out.WriteByte(']')
+		return out.String(), nil
+	case *Instance:
+		method := specialMethod(obj, "__str")
+		if method == nil {
+			break
+		}
+
+		value, err := inter.callSpecial(tok, method)
+		if err != nil {
+			return "", err
+		}
+		s, ok := value.(*String)
+		if !ok {
+			return "", callError(tok, BIError("Method "+method.declaration.Name.Lexeme+" must return a string."))
+		}
+		return s.Value, nil
+	}
+
+	return obj.String(), nil
+}
@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// newRandomModule returns the random module. Its functions draw from the generator of the calling interpreter, so a
+// seeded interpreter always produces the same results.
+func newRandomModule() *Module {
+	return newModule("random", map[string]object.Object{
+		"random":   newBuiltin(0, bRandom),
+		"randInt":  newBuiltin(2, bRandInt),
+		"choice":   newBuiltin(1, bRandChoice),
+		"shuffle":  newBuiltin(1, bRandShuffle),
+		"weighted": newBuiltin(2, bRandWeighted),
+		"roll":     newBuiltin(1, bRandRoll),
+		"seed":     newBuiltin(1, bRandSeed),
+	})
+}
+
+// bRandom returns a float in the range 0 up to but not including 1.
+func bRandom(interp *Interpreter, args []object.Object) (object.Object, error) {
+	return &Number{Float: interp.rand.Float64()}, nil
+}
+
+// bRandInt returns an integer between low and high inclusive.
+func bRandInt(interp *Interpreter, args []object.Object) (object.Object, error) {
+	nums, err := numberArgs("random.randInt", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	low, high := nums[0], nums[1]
+	if !low.IsInt || !high.IsInt {
+		return NullOb, BIError("'random.randInt' arguments must be integers.")
+	}
+	if numberLess(high, low) {
+		return NullOb, BIError("'random.randInt' low must not be greater than high.")
+	}
+
+	if low.Big == nil && high.Big == nil && high.Int-low.Int >= 0 && high.Int-low.Int < math.MaxInt {
+		return &Number{IsInt: true, Int: low.Int + int(interp.rand.Int63n(int64(high.Int-low.Int+1)))}, nil
+	}
+
+	span := new(big.Int).Sub(high.bigInt(), low.bigInt())
+	span.Add(span, big.NewInt(1))
+	n := new(big.Int).Rand(interp.rand, span)
+	return newBigNumber(n.Add(n, low.bigInt())), nil
+}
+
+// bRandChoice returns a random element of a non-empty list.
+func bRandChoice(interp *Interpreter, args []object.Object) (object.Object, error) {
+	l, ok := args[0].(*List)
+	if !ok {
+		return NullOb, BIError("'random.choice' argument must be of a type LIST.")
+	}
+	if len(l.Elements) == 0 {
+		return NullOb, BIError("'random.choice' list must not be empty.")
+	}
+
+	return l.Elements[interp.rand.Intn(len(l.Elements))], nil
+}
+
+// bRandShuffle shuffles the elements of a list in place and returns the list.
+func bRandShuffle(interp *Interpreter, args []object.Object) (object.Object, error) {
+	l, ok := args[0].(*List)
+	if !ok {
+		return NullOb, BIError("'random.shuffle' argument must be of a type LIST.")
+	}
+	if l.frozen {
+		return NullOb, BIError("'random.shuffle' cannot modify a frozen list.")
+	}
+
+	interp.rand.Shuffle(len(l.Elements), func(i, j int) {
+		l.Elements[i], l.Elements[j] = l.Elements[j], l.Elements[i]
+	})
+	return l, nil
+}
+
+// bRandWeighted returns a random element of a list of items, where each item is chosen in proportion to the weight
+// at the same position in a list of weights.
+func bRandWeighted(interp *Interpreter, args []object.Object) (object.Object, error) {
+	items, ok := args[0].(*List)
+	weights, ok2 := args[1].(*List)
+	if !ok || !ok2 {
+		return NullOb, BIError("'random.weighted' arguments must be a list of items and a list of weights.")
+	}
+	if len(items.Elements) != len(weights.Elements) {
+		return NullOb, BIError("'random.weighted' must have one weight for each item.")
+	}
+
+	nums, err := numberArgs("random.weighted", weights.Elements)
+	if err != nil {
+		return NullOb, BIError("'random.weighted' weights must be numbers.")
+	}
+
+	var total float64
+	for _, n := range nums {
+		w := n.float()
+		if w < 0 {
+			return NullOb, BIError("'random.weighted' weights must not be negative.")
+		}
+		total += w
+	}
+	if total <= 0 {
+		return NullOb, BIError("'random.weighted' weights must not all be zero.")
+	}
+
+	r := interp.rand.Float64() * total
+	last := 0
+	for i, n := range nums {
+		if n.float() == 0 {
+			continue
+		}
+		last = i
+		if r < n.float() {
+			return items.Elements[i], nil
+		}
+		r -= n.float()
+	}
+
+	// Only reached through floating point rounding; the last item with a weight is the correct choice.
+	return items.Elements[last], nil
+}
+
+// maxDice and maxRoll limit the dice random.roll accepts, so that rolling is quick and the total, and its modifier,
+// fit in an int on every platform.
+const (
+	maxDice = 1000
+	maxRoll = 1000000000
+)
+
+var diceNotation = regexp.MustCompile(`^\s*(\d*)\s*[dD]\s*(\d+|%)\s*(?:([+-])\s*(\d+))?\s*$`)
+
+// bRandRoll rolls dice written in dice notation, such as "d20", "3d6+2" or "2d10-1", and returns the total. "d%" is
+// a hundred sided die.
+func bRandRoll(interp *Interpreter, args []object.Object) (object.Object, error) {
+	s, ok := args[0].(*String)
+	if !ok {
+		return NullOb, BIError("'random.roll' argument must be of a type STRING.")
+	}
+
+	m := diceNotation.FindStringSubmatch(s.Value)
+	if m == nil {
+		return NullOb, BIError("'random.roll' could not parse dice " + strconv.Quote(s.Value) + ".")
+	}
+
+	count := 1
+	if m[1] != "" {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > maxDice {
+			return NullOb, BIError("'random.roll' must roll between 1 and " + strconv.Itoa(maxDice) + " dice.")
+		}
+		count = n
+	}
+	sides := 100
+	if m[2] != "%" {
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n > maxRoll {
+			return NullOb, BIError("'random.roll' dice must have at most " + strconv.Itoa(maxRoll) + " sides.")
+		}
+		if n < 1 {
+			return NullOb, BIError("'random.roll' dice must have at least one side.")
+		}
+		sides = n
+	}
+	if sides > maxRoll/count {
+		return NullOb, BIError("'random.roll' dice must not total more than " + strconv.Itoa(maxRoll) + ".")
+	}
+
+	total := 0
+	for i := 0; i < count; i++ {
+		total += interp.rand.Intn(sides) + 1
+	}
+
+	if m[3] != "" {
+		mod, err := strconv.Atoi(m[4])
+		if err != nil || mod > maxRoll {
+			return NullOb, BIError("'random.roll' modifier is too large.")
+		}
+		if m[3] == "-" {
+			mod = -mod
+		}
+		total += mod
+	}
+
+	return &Number{IsInt: true, Int: total}, nil
+}
+
+// bRandSeed reseeds the generator of the calling interpreter.
+func bRandSeed(interp *Interpreter, args []object.Object) (object.Object, error) {
+	n, ok := args[0].(*Number)
+	if !ok || !n.IsInt || n.Big != nil {
+		return NullOb, BIError("'random.seed' argument must be an integer.")
+	}
+
+	interp.SetSeed(int64(n.Int))
+	return NullOb, nil
+}
@@ -0,0 +1,165 @@
+package interpreter
+
+import (
+	"sort"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+)
+
+// bTypeOf returns the name of the type of its argument, such as "NUMBER" or "INSTANCE".
+func bTypeOf(interp *Interpreter, args []object.Object) (object.Object, error) {
+	return &String{Value: args[0].Type().String()}, nil
+}
+
+// bClassOf returns the class of an instance, or null for any other value.
+func bClassOf(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if inst, ok := args[0].(*Instance); ok {
+		return inst.klass, nil
+	}
+	return NullOb, nil
+}
+
+// bIsInstance reports whether a value is an instance of a class, one of its subclasses, or a class which mixes in
+// a trait.
+func bIsInstance(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if args[1].Type() != object.Class && args[1].Type() != object.Trait {
+		return NullOb, BIError("'isInstance' second argument must be of a type CLASS or TRAIT.")
+	}
+
+	if inst, ok := args[0].(*Instance); ok && instanceOf(inst, args[1]) {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bFields returns the names of the fields of an instance in sorted order.
+func bFields(interp *Interpreter, args []object.Object) (object.Object, error) {
+	inst, ok := args[0].(*Instance)
+	if !ok {
+		return NullOb, BIError("'fields' argument must be of a type INSTANCE.")
+	}
+
+	names := make([]string, 0, len(inst.fields))
+	for name := range inst.fields {
+		names = append(names, name)
+	}
+	return stringList(names), nil
+}
+
+// bMethods returns the names of the methods of a class, or of the class of an instance, in sorted order. Inherited
+// methods and those of mixed in traits are included.
+func bMethods(interp *Interpreter, args []object.Object) (object.Object, error) {
+	klass, ok := args[0].(*Class)
+	if inst, isInst := args[0].(*Instance); isInst {
+		klass, ok = inst.klass, true
+	}
+	if !ok {
+		return NullOb, BIError("'methods' argument must be of a type CLASS or INSTANCE.")
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for k := klass; k != nil; k = k.superclass {
+		for name := range k.methods {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return stringList(names), nil
+}
+
+// bHasField reports whether an instance has a field with the given name.
+func bHasField(interp *Interpreter, args []object.Object) (object.Object, error) {
+	inst, name, err := propertyArgs("hasField", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	if _, ok := inst.fields[name]; ok {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bHasMethod reports whether an instance has a method with the given name.
+func bHasMethod(interp *Interpreter, args []object.Object) (object.Object, error) {
+	inst, name, err := propertyArgs("hasMethod", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	if inst.klass.findMethod(inst, name) != nil {
+		return True, nil
+	}
+	return False, nil
+}
+
+// bGetField returns the property of an instance with the given name, exactly as a get expression would: a getter,
+// a field or a bound method.
+func bGetField(interp *Interpreter, args []object.Object) (object.Object, error) {
+	inst, name, err := propertyArgs("getField", args)
+	if err != nil {
+		return NullOb, err
+	}
+
+	_, isField := inst.fields[name]
+	if !isField && inst.klass.findAccessor(name, false) == nil && inst.klass.findMethod(inst, name) == nil {
+		return NullOb, BIError("'getField' instance has no property " + interp.repr(args[1]) + ".")
+	}
+
+	return inst.Get(interp, lexer.NewToken(lexer.Ident, name, "", 0))
+}
+
+// bSetField assigns the property of an instance with the given name, exactly as a set expression would, and
+// returns the value.
+func bSetField(interp *Interpreter, args []object.Object) (object.Object, error) {
+	inst, name, err := propertyArgs("setField", args[:2])
+	if err != nil {
+		return NullOb, err
+	}
+
+	if inst.klass.findAccessor(name, true) == nil && inst.klass.findAccessor(name, false) != nil {
+		return NullOb, BIError("'setField' property " + interp.repr(args[1]) + " is read-only.")
+	}
+
+	if err := inst.Set(interp, lexer.NewToken(lexer.Ident, name, "", 0), args[2]); err != nil {
+		return NullOb, err
+	}
+	return args[2], nil
+}
+
+// bArity returns the number of arguments a function, method or class expects, or -1 if it accepts any number.
+func bArity(interp *Interpreter, args []object.Object) (object.Object, error) {
+	fn, ok := args[0].(Callable)
+	if !ok {
+		return NullOb, BIError("'arity' argument must be a function or class.")
+	}
+	return &Number{IsInt: true, Int: fn.Arity()}, nil
+}
+
+// propertyArgs checks the instance and property name arguments of the property builtins.
+func propertyArgs(builtin string, args []object.Object) (*Instance, string, error) {
+	inst, ok := args[0].(*Instance)
+	if !ok {
+		return nil, "", BIError("'" + builtin + "' first argument must be of a type INSTANCE.")
+	}
+	name, ok := args[1].(*String)
+	if !ok {
+		return nil, "", BIError("'" + builtin + "' property name must be of a type STRING.")
+	}
+
+	return inst, name.Value, nil
+}
+
+// stringList returns a list of the names, sorted.
+func stringList(names []string) *List {
+	sort.Strings(names)
+	l := &List{Elements: make([]object.Object, len(names))}
+	for i, name := range names {
+		l.Elements[i] = &String{Value: name}
+	}
+	return l
+}
@@ -0,0 +1,405 @@
+package interpreter
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// taskGroup is shared by an interpreter, its forks and the interpreters of the files it imports. Only the goroutine
+// holding mu runs script code, so tasks take turns: a task lets the others run while it waits on a channel or for
+// another task, and when it finishes. The host holds mu while it calls into scripts.
+type taskGroup struct {
+	mu      sync.Mutex
+	running sync.WaitGroup
+	failed  []*Task
+	active  int           // Calls from the host and spawned tasks which are running script code or waiting.
+	waiting int           // Of the active tasks, those waiting for a channel made by a script or for another task.
+	host    int           // Calls from the host waiting for a script, including the host waiting in Wait.
+	moves   int           // Incremented whenever a task stops waiting, or starts or finishes.
+	stuck   chan struct{} // Closed to wake the waiting tasks when none of them can ever continue.
+}
+
+func newTaskGroup() *taskGroup {
+	return &taskGroup{stuck: make(chan struct{})}
+}
+
+// AllWaiting is returned to each waiting task when every task waits for another, and the host waits for them, so that
+// none can ever continue.
+const AllWaiting = BIError("All tasks are waiting, so none of them can continue.")
+
+// stuckDelay is how long every task must have been waiting before they are stopped. A task woken just before the
+// last one started waiting still counts as waiting until it takes the lock again.
+const stuckDelay = 20 * time.Millisecond
+
+// enter takes the lock for a call from the host, or a spawned task, which runs script code.
+func (g *taskGroup) enter() {
+	g.mu.Lock()
+	g.active++
+	g.moves++
+}
+
+// leave releases the lock taken by enter.
+func (g *taskGroup) leave() {
+	g.active--
+	g.moves++
+	g.mu.Unlock()
+}
+
+// watch stops the waiting tasks if every task is still waiting a moment after the last started to. The host must be
+// waiting for them too, as otherwise it may yet call a script which wakes them.
+func (g *taskGroup) watch() {
+	if g.active == 0 || g.waiting < g.active || g.host == 0 {
+		return
+	}
+
+	moves := g.moves
+	time.AfterFunc(stuckDelay, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.moves == moves && g.waiting == g.active && g.host > 0 {
+			close(g.stuck)
+			g.stuck = make(chan struct{})
+		}
+	})
+}
+
+// blocking calls wait, which blocks on a channel or another task, with the lock released so that other tasks can run
+// in the meantime. wait must also return once stuck is closed, when blocking fails with AllWaiting. A wait which the
+// host may end, by using a channel it made, never counts towards every task waiting.
+func (inter *Interpreter) blocking(host bool, wait func(stuck <-chan struct{})) (err error) {
+	g := inter.tasks
+	stuck := g.stuck
+	if !host {
+		inter.waiting(1)
+		g.watch()
+	}
+
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		if !host {
+			inter.waiting(-1)
+			g.moves++
+		}
+		select {
+		case <-stuck:
+			err = AllWaiting
+		default:
+		}
+	}()
+
+	wait(stuck)
+	return nil
+}
+
+// waiting adds n to the tasks waiting, counting a call from the host as the host waiting too.
+func (inter *Interpreter) waiting(n int) {
+	inter.tasks.waiting += n
+	if inter.currentTask() == nil {
+		inter.tasks.host += n
+	}
+}
+
+// currentTask returns the spawned task running script code on the interpreter, or nil for a call from the host. The
+// body of a generator runs as part of the task which resumed it.
+func (inter *Interpreter) currentTask() *Task {
+	if inter.gen != nil {
+		return inter.gen.resumer
+	}
+	return inter.task
+}
+
+// Wait blocks until every task spawned by scripts has finished, and returns the errors of those which failed without
+// being joined.
+func (inter *Interpreter) Wait() []error {
+	inter.tasks.mu.Lock()
+	inter.tasks.host++
+	inter.tasks.watch()
+	inter.tasks.mu.Unlock()
+
+	inter.tasks.running.Wait()
+
+	inter.tasks.mu.Lock()
+	defer inter.tasks.mu.Unlock()
+
+	inter.tasks.host--
+
+	var errs []error
+	for _, t := range inter.tasks.failed {
+		if !t.joined {
+			errs = append(errs, t.err)
+		}
+	}
+	inter.tasks.failed = nil
+	return errs
+}
+
+// Task is a call started by spawn, which runs on its own goroutine with a fork of the interpreter.
+type Task struct {
+	name   string
+	done   chan struct{} // Closed when the call returns.
+	value  object.Object
+	err    error
+	joined bool
+}
+
+func (t *Task) Type() object.Type { return object.Task }
+func (t *Task) String() string    { return "<task " + t.name + ">" }
+
+// VisitSpawnExpr evaluates the function and arguments of the call in the current task, then makes the call in a new
+// task and returns it without waiting.
+func (inter *Interpreter) VisitSpawnExpr(expr *object.SpawnExpr) (object.Object, error) {
+	function, args, err := inter.callee(expr.Call)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Task{name: callableName(function), done: make(chan struct{})}
+	fork := inter.fork()
+	fork.gen = nil
+	fork.task = t
+
+	inter.tasks.running.Add(1)
+	go func() {
+		defer inter.tasks.running.Done()
+
+		fork.tasks.enter()
+		defer fork.tasks.leave()
+
+		t.value, t.err = function.Call(fork, args)
+		if t.err != nil {
+			t.err = callError(expr.Call.Paren, t.err)
+			fork.tasks.failed = append(fork.tasks.failed, t)
+		}
+		close(t.done)
+	}()
+
+	return t, nil
+}
+
+// callableName returns the name of a function or class for describing a task which calls it.
+func callableName(fn Callable) string {
+	switch f := fn.(type) {
+	case *Function:
+		return f.declaration.Name.Lexeme
+	case *Class:
+		return f.Name
+	}
+	return "builtin"
+}
+
+// Channel passes values between tasks, and between scripts and the host. A channel with a capacity holds up to that
+// many values which have been sent but not received. The host may send and receive on C directly, and must only send
+// Objects of this package, but must close the channel with Close. The host must only use channels it made with
+// NewChannel this way, as a task waiting on a channel made by a script is only expected to be woken by another task.
+type Channel struct {
+	C      chan object.Object
+	mu     sync.Mutex
+	closed bool
+	host   bool // Set for channels made by the host, which it may send on or receive from at any time.
+}
+
+// NewChannel returns a channel which holds up to capacity values before a send waits for them to be received.
+func NewChannel(capacity int) *Channel {
+	return &Channel{C: make(chan object.Object, capacity), host: true}
+}
+
+func (c *Channel) Type() object.Type { return object.Channel }
+func (c *Channel) String() string    { return "<channel " + strconv.Itoa(cap(c.C)) + ">" }
+
+// Close closes the channel, after which no more values can be sent on it. It reports false if the channel was
+// already closed.
+func (c *Channel) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.C)
+	return true
+}
+
+func (c *Channel) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// send sends value on the channel, waiting until there is room for it. Sending on a closed channel fails.
+func (c *Channel) send(inter *Interpreter, value object.Object) (err error) {
+	if c.isClosed() {
+		return BIError("Cannot send on a closed channel.")
+	}
+
+	// The channel may be closed by another task while this one waits.
+	defer func() {
+		if recover() != nil {
+			err = BIError("Cannot send on a closed channel.")
+		}
+	}()
+	return inter.blocking(c.host, func(stuck <-chan struct{}) {
+		select {
+		case c.C <- value:
+		case <-stuck:
+		}
+	})
+}
+
+// recv waits for a value from the channel. It returns false once the channel is closed and every value sent has been
+// received.
+func (c *Channel) recv(inter *Interpreter) (object.Object, bool, error) {
+	var value object.Object
+	ok := false
+	err := inter.blocking(c.host, func(stuck <-chan struct{}) {
+		select {
+		case value, ok = <-c.C:
+		case <-stuck:
+		}
+	})
+	if value == nil {
+		value = NullOb
+	}
+	return value, ok, err
+}
+
+// channelArg returns the first argument of the builtin name, which must be a channel.
+func channelArg(name string, args []object.Object) (*Channel, error) {
+	c, ok := args[0].(*Channel)
+	if !ok {
+		return nil, BIError("'" + name + "' argument must be of a type CHANNEL.")
+	}
+	return c, nil
+}
+
+// bChannel returns a new channel, which holds up to the optional capacity values before a send waits.
+func bChannel(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) > 1 {
+		return NullOb, BIError("'channel' expects an optional capacity.")
+	}
+
+	capacity := 0
+	if len(args) == 1 {
+		n, ok := args[0].(*Number)
+		if !ok || !n.IsInt || n.Big != nil || n.Int < 0 {
+			return NullOb, BIError("'channel' capacity must be a non-negative integer.")
+		}
+		capacity = n.Int
+	}
+	return &Channel{C: make(chan object.Object, capacity)}, nil
+}
+
+// bSend sends a value on a channel, waiting until it is received or there is room for it.
+func bSend(interp *Interpreter, args []object.Object) (object.Object, error) {
+	c, err := channelArg("send", args)
+	if err != nil {
+		return NullOb, err
+	}
+	return NullOb, c.send(interp, args[1])
+}
+
+// bRecv waits for a value from a channel and returns it, or null once the channel is closed and empty.
+func bRecv(interp *Interpreter, args []object.Object) (object.Object, error) {
+	c, err := channelArg("recv", args)
+	if err != nil {
+		return NullOb, err
+	}
+	value, _, err := c.recv(interp)
+	return value, err
+}
+
+// bClose closes a channel, after which no more values can be sent on it.
+func bClose(interp *Interpreter, args []object.Object) (object.Object, error) {
+	c, err := channelArg("close", args)
+	if err != nil {
+		return NullOb, err
+	}
+	if !c.Close() {
+		return NullOb, BIError("'close' channel is already closed.")
+	}
+	return NullOb, nil
+}
+
+// bSelect waits until any of a list of channels has a value, or is closed, and returns a list of the channel and the
+// value received, which is null for a closed channel. When the optional second argument is false and no channel is
+// ready, it returns null instead of waiting.
+func bSelect(interp *Interpreter, args []object.Object) (object.Object, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return NullOb, BIError("'select' expects a list of channels and an optional wait.")
+	}
+
+	list, ok := args[0].(*List)
+	if !ok {
+		return NullOb, BIError("'select' argument must be of a type LIST.")
+	}
+	wait := len(args) == 1 || isTruthy(args[1])
+
+	cases := make([]reflect.SelectCase, len(list.Elements))
+	host := false
+	for i, el := range list.Elements {
+		c, ok := el.(*Channel)
+		if !ok {
+			return NullOb, BIError("'select' list must only hold channels.")
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.C)}
+		host = host || c.host
+	}
+	if len(cases) == 0 && wait {
+		return NullOb, BIError("'select' cannot wait on an empty list.")
+	}
+	if !wait {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	var chosen int
+	var value reflect.Value
+	var received bool
+	// A select which does not wait is never stuck.
+	err := interp.blocking(host || !wait, func(stuck <-chan struct{}) {
+		cases := append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stuck)})
+		chosen, value, received = reflect.Select(cases)
+	})
+	if err != nil {
+		return NullOb, err
+	}
+
+	if chosen == len(list.Elements) {
+		return NullOb, nil
+	}
+	var v object.Object = NullOb
+	if received && !value.IsNil() {
+		v = value.Interface().(object.Object)
+	}
+	return &List{Elements: []object.Object{list.Elements[chosen], v}}, nil
+}
+
+// bJoin waits for a task to finish and returns the value its call returned. If the call failed, join fails with
+// its error.
+func bJoin(interp *Interpreter, args []object.Object) (object.Object, error) {
+	t, ok := args[0].(*Task)
+	if !ok {
+		return NullOb, BIError("'join' argument must be of a type TASK.")
+	}
+
+	err := interp.blocking(false, func(stuck <-chan struct{}) {
+		select {
+		case <-t.done:
+		case <-stuck:
+		}
+	})
+	if err != nil {
+		return NullOb, err
+	}
+	t.joined = true
+	if t.err != nil {
+		return NullOb, t.err
+	}
+	return t.value, nil
+}
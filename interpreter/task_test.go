@@ -0,0 +1,107 @@
+package interpreter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/parser"
+)
+
+func TestHostChannels(t *testing.T) {
+	input := `
+var commands = null;
+var replies = null;
+
+fn listen() {
+  for (var cmd in commands) {
+    send(replies, "You say: " + cmd);
+  }
+  close(replies);
+}
+
+fn main() {
+  spawn listen();
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "host.glpc")), "host.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+
+	commands, replies := NewChannel(0), NewChannel(0)
+	env.DefineString("commands", commands)
+	env.DefineString("replies", replies)
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run main: %v", err)
+	}
+
+	// The listening task keeps running after main returns, and is driven by the host.
+	for _, cmd := range []string{"hello", "bye"} {
+		commands.C <- &String{Value: cmd}
+		reply := <-replies.C
+		if reply.String() != "You say: "+cmd {
+			t.Errorf("wrong reply. expected=%q, got=%q", "You say: "+cmd, reply.String())
+		}
+	}
+
+	commands.Close()
+	if _, ok := <-replies.C; ok {
+		t.Errorf("expected the script to close the replies channel")
+	}
+	if errs := interp.Wait(); len(errs) != 0 {
+		t.Errorf("unexpected task errors: %v", errs)
+	}
+}
+
+func TestWaitingForHost(t *testing.T) {
+	input := `
+var said = channel();
+var heard = "";
+
+fn listen() {
+  for (var line in said) {
+    heard = heard + line;
+  }
+}
+
+fn main() {
+  spawn listen();
+  on("say", relay);
+}
+
+fn relay(line) {
+  send(said, line);
+}
+`
+
+	interp := New()
+	env, err := interp.Interpret(parser.New(lexer.New([]byte(input), "relay.glpc")), "relay.glpc")
+	if err != nil {
+		t.Fatalf("unable to interpret script: %v", err)
+	}
+	if err := interp.RunMain(env); err != nil {
+		t.Fatalf("unable to run main: %v", err)
+	}
+
+	// Only the host can wake the listening task, by emitting an event, so it must not be stopped for waiting.
+	time.Sleep(2 * stuckDelay)
+	if _, errs := interp.Emit("say", &String{Value: "hello"}); len(errs) != 0 {
+		t.Fatalf("unexpected handler errors: %v", errs)
+	}
+
+	interp.tasks.enter()
+	closed := env.GetString("said").(*Channel).Close()
+	interp.tasks.leave()
+	if !closed {
+		t.Errorf("expected the channel to still be open")
+	}
+	if errs := interp.Wait(); len(errs) != 0 {
+		t.Errorf("unexpected task errors: %v", errs)
+	}
+	if heard := env.GetString("heard").String(); heard != "hello" {
+		t.Errorf("wrong lines heard. expected=%q, got=%q", "hello", heard)
+	}
+}
@@ -0,0 +1,76 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/butlermatt/glpc/object"
+)
+
+// Trait holds methods, accessors and fields which classes mix in with a with clause.
+type Trait struct {
+	Name    string
+	decl    *object.TraitStmt
+	closure *object.Environment
+}
+
+func (t *Trait) Type() object.Type { return object.Trait }
+func (t *Trait) String() string    { return t.Name }
+
+// mixin returns a class holding the members of the trait with next as its superclass. Every class mixing in the
+// trait gets its own copy, placed between the class and its superclass, so that super within the trait refers to
+// whatever follows it in that class.
+func (t *Trait) mixin(next *Class) *Class {
+	env := object.NewEnclosedEnvironment(t.closure)
+	if next != nil {
+		env.DefineString("super", next)
+	} else {
+		env.DefineString("super", NullOb)
+	}
+
+	return &Class{
+		Name:       t.Name,
+		superclass: next,
+		methods:    newMethods(t.decl.Methods, env),
+		getters:    newMethods(t.decl.Getters, env),
+		setters:    newMethods(t.decl.Setters, env),
+		fields:     t.decl.Fields,
+		closure:    env,
+		trait:      t,
+	}
+}
+
+// checkTraitConflicts returns an error if more than one of the traits defines the same method or accessor and the
+// class does not define it itself, as it would be ambiguous which the class should use.
+func checkTraitConflicts(stmt *object.ClassStmt, traits []*Trait) error {
+	kinds := []struct {
+		own    []*object.FunctionStmt
+		traits func(*object.TraitStmt) []*object.FunctionStmt
+	}{
+		{stmt.Methods, func(t *object.TraitStmt) []*object.FunctionStmt { return t.Methods }},
+		{stmt.Getters, func(t *object.TraitStmt) []*object.FunctionStmt { return t.Getters }},
+		{stmt.Setters, func(t *object.TraitStmt) []*object.FunctionStmt { return t.Setters }},
+	}
+
+	for _, kind := range kinds {
+		defined := make(map[string]string)
+		for _, fn := range kind.own {
+			defined[fn.Name.Lexeme] = ""
+		}
+
+		for _, t := range traits {
+			for _, fn := range kind.traits(t.decl) {
+				name := fn.Name.Lexeme
+				other, ok := defined[name]
+				if ok && other != "" {
+					msg := fmt.Sprintf("%s must override %s, which is defined by both %s and %s.", stmt.Name.Lexeme, name, other, t.Name)
+					return object.NewRuntimeError(stmt.Name, msg)
+				}
+				if !ok {
+					defined[name] = t.Name
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"github.com/butlermatt/glpc/object"
+)
+
+// checkType returns a BIError describing the mismatch if value does not have the type t, where what names the
+// variable, field, parameter or return value being checked. A nil type accepts any value. Class and trait names are
+// looked up in env when the check is made, and a name which is neither is reported at the annotation.
+func (inter *Interpreter) checkType(t *object.TypeAnnotation, value object.Object, env *object.Environment, what string) error {
+	if t == nil {
+		return nil
+	}
+
+	ok, err := inter.hasType(t, value, env)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	return BIError(what + " must be of type " + t.String() + ", got " + typeName(value) + ".")
+}
+
+// hasType reports whether value has the type t.
+func (inter *Interpreter) hasType(t *object.TypeAnnotation, value object.Object, env *object.Environment) (bool, error) {
+	if value.Type() == object.Null {
+		return t.Nullable || t.Name.Lexeme == "any", nil
+	}
+
+	switch t.Name.Lexeme {
+	case "any":
+		return true, nil
+	case "bool":
+		return value.Type() == object.Boolean, nil
+	case "int", "float", "num":
+		n, ok := value.(*Number)
+		return ok && (t.Name.Lexeme == "num" || n.IsInt == (t.Name.Lexeme == "int")), nil
+	case "string":
+		return value.Type() == object.String, nil
+	case "list":
+		return value.Type() == object.List, nil
+	case "fn":
+		return value.Type() == object.Function || value.Type() == object.BuiltIn, nil
+	case "generator":
+		return value.Type() == object.Generator, nil
+	case "channel":
+		return value.Type() == object.Channel, nil
+	case "task":
+		return value.Type() == object.Task, nil
+	}
+
+	typ, err := env.Get(t.Name)
+	if err != nil {
+		typ = inter.globals.GetString(t.Name.Lexeme)
+	}
+	if typ == nil || typ.Type() != object.Class && typ.Type() != object.Trait {
+		return false, object.NewRuntimeError(t.Name, "Unknown type "+t.Name.Lexeme+".")
+	}
+
+	inst, ok := value.(*Instance)
+	return ok && instanceOf(inst, typ), nil
+}
+
+// instanceOf reports whether inst is an instance of the class or trait typ, either directly, through a superclass,
+// or through a trait its class mixes in.
+func instanceOf(inst *Instance, typ object.Object) bool {
+	for k := inst.klass; k != nil; k = k.superclass {
+		if k == typ || k.trait != nil && k.trait == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName describes the type of value with the names used in type annotations. Instances are described by the name
+// of their class.
+func typeName(value object.Object) string {
+	switch v := value.(type) {
+	case *Null:
+		return "null"
+	case *Boolean:
+		return "bool"
+	case *Number:
+		if v.IsInt {
+			return "int"
+		}
+		return "float"
+	case *String:
+		return "string"
+	case *List:
+		return "list"
+	case *Function, *BuiltIn:
+		return "fn"
+	case *Generator:
+		return "generator"
+	case *Channel:
+		return "channel"
+	case *Task:
+		return "task"
+	case *Instance:
+		return v.klass.Name
+	}
+	return value.Type().String()
+}
+
+// fieldType returns the type annotation of the field name declared in fields, or nil if it has none.
+func fieldType(fields []*object.VarStmt, name string) *object.TypeAnnotation {
+	for _, field := range fields {
+		if field.Name.Lexeme == name {
+			return field.Type
+		}
+	}
+	return nil
+}
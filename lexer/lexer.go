@@ -1,5 +1,12 @@
 package lexer
 
+import (
+	"bytes"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
 type Lexer struct {
 	file    string
 	input   []byte
@@ -7,8 +14,9 @@ type Lexer struct {
 	start   int
 	current int
 
-	tokens []*Token // Scanned tokens
-	index  int      // Index in token list.
+	tokens  []*Token // Scanned tokens
+	index   int      // Index in token list.
+	interps []int    // Open brace depth of each unfinished string interpolation.
 }
 
 // New returns a new Lexer populated with the specified input program.
@@ -27,6 +35,14 @@ func (l *Lexer) NextToken() *Token {
 	return tok
 }
 
+// PeekToken returns the token which the next call to NextToken will provide without consuming it.
+func (l *Lexer) PeekToken() *Token {
+	if l.index >= len(l.tokens) {
+		return nil
+	}
+	return l.tokens[l.index]
+}
+
 // ScanTokens will scan all the input and generate a slice of tokens.
 func (l *Lexer) ScanTokens() {
 	for !l.isAtEnd() {
@@ -49,8 +65,22 @@ func (l *Lexer) scanToken() {
 		l.addTokenType(Colon)
 	case ',':
 		l.addTokenType(Comma)
+	case '?':
+		if l.match('?') {
+			l.addTokenType(QuestionQuestion)
+		} else if l.match('.') {
+			l.addTokenType(QuestionDot)
+		} else {
+			l.addTokenType(Question)
+		}
 	case '.':
-		l.addTokenType(Dot)
+		if l.peek() == '.' && l.peekNext() == '.' {
+			l.readChar()
+			l.readChar()
+			l.addTokenType(Ellipsis)
+		} else {
+			l.addTokenType(Dot)
+		}
 	case ';':
 		l.addTokenType(Semicolon)
 	case '(':
@@ -58,8 +88,20 @@ func (l *Lexer) scanToken() {
 	case ')':
 		l.addTokenType(RParen)
 	case '{':
+		if n := len(l.interps); n > 0 {
+			l.interps[n-1] += 1
+		}
 		l.addTokenType(LBrace)
 	case '}':
+		if n := len(l.interps); n > 0 {
+			if l.interps[n-1] == 0 {
+				// End of the embedded expression, resume scanning the string it was in.
+				l.interps = l.interps[:n-1]
+				l.string('"')
+				return
+			}
+			l.interps[n-1] -= 1
+		}
 		l.addTokenType(RBrace)
 	case '[':
 		l.addTokenType(LBracket)
@@ -68,12 +110,16 @@ func (l *Lexer) scanToken() {
 	case '-':
 		if l.match('=') {
 			l.addTokenType(MinusEq)
+		} else if l.match('-') {
+			l.addTokenType(MinusMinus)
 		} else {
 			l.addTokenType(Minus)
 		}
 	case '+':
 		if l.match('=') {
 			l.addTokenType(PlusEq)
+		} else if l.match('+') {
+			l.addTokenType(PlusPlus)
 		} else {
 			l.addTokenType(Plus)
 		}
@@ -86,9 +132,33 @@ func (l *Lexer) scanToken() {
 	case '*':
 		if l.match('=') {
 			l.addTokenType(StarEq)
+		} else if l.match('*') {
+			if l.match('=') {
+				l.addTokenType(StarStarEq)
+			} else {
+				l.addTokenType(StarStar)
+			}
 		} else {
 			l.addTokenType(Star)
 		}
+	case '&':
+		if l.match('=') {
+			l.addTokenType(AmpEq)
+		} else {
+			l.addTokenType(Amp)
+		}
+	case '|':
+		if l.match('=') {
+			l.addTokenType(PipeEq)
+		} else {
+			l.addTokenType(Pipe)
+		}
+	case '^':
+		if l.match('=') {
+			l.addTokenType(CaretEq)
+		} else {
+			l.addTokenType(Caret)
+		}
 	case '~':
 		if l.match('/') {
 			if l.match('=') {
@@ -97,7 +167,7 @@ func (l *Lexer) scanToken() {
 				l.addTokenType(TildSlash)
 			}
 		} else {
-			l.addTokenType(Illegal)
+			l.addTokenType(Tild)
 		}
 	case '/':
 		if l.match('/') {
@@ -113,18 +183,32 @@ func (l *Lexer) scanToken() {
 	case '=':
 		if l.match('=') {
 			l.addTokenType(EqualEq)
+		} else if l.match('>') {
+			l.addTokenType(Arrow)
 		} else {
 			l.addTokenType(Equal)
 		}
 	case '>':
 		if l.match('=') {
 			l.addTokenType(GreaterEq)
+		} else if l.match('>') {
+			if l.match('=') {
+				l.addTokenType(GreaterGreaterEq)
+			} else {
+				l.addTokenType(GreaterGreater)
+			}
 		} else {
 			l.addTokenType(Greater)
 		}
 	case '<':
 		if l.match('=') {
 			l.addTokenType(LessEq)
+		} else if l.match('<') {
+			if l.match('=') {
+				l.addTokenType(LessLessEq)
+			} else {
+				l.addTokenType(LessLess)
+			}
 		} else {
 			l.addTokenType(Less)
 		}
@@ -157,33 +241,42 @@ func (l *Lexer) addToken(token *Token) {
 	l.tokens = append(l.tokens, token)
 }
 
-func (l *Lexer) match(char byte) bool {
-	if l.isAtEnd() || l.input[l.current] != char {
+func (l *Lexer) match(char rune) bool {
+	if l.isAtEnd() || l.peek() != char {
 		return false
 	}
 
-	l.current += 1
+	l.readChar()
 	return true
 }
 
-func (l *Lexer) peek() byte {
+// peek returns the character at the current position without consuming it. Input is decoded as UTF-8; invalid
+// bytes are returned as utf8.RuneError.
+func (l *Lexer) peek() rune {
 	if l.isAtEnd() {
 		return 0
 	}
-	return l.input[l.current]
+	ch, _ := utf8.DecodeRune(l.input[l.current:])
+	return ch
 }
 
-func (l *Lexer) peekNext() byte {
-	if l.current+1 >= len(l.input) {
+func (l *Lexer) peekNext() rune {
+	if l.isAtEnd() {
+		return 0
+	}
+
+	_, size := utf8.DecodeRune(l.input[l.current:])
+	if l.current+size >= len(l.input) {
 		return 0
 	}
 
-	return l.input[l.current+1]
+	ch, _ := utf8.DecodeRune(l.input[l.current+size:])
+	return ch
 }
 
-func (l *Lexer) readChar() byte {
-	ch := l.input[l.current]
-	l.current += 1
+func (l *Lexer) readChar() rune {
+	ch, size := utf8.DecodeRune(l.input[l.current:])
+	l.current += size
 	return ch
 }
 
@@ -200,27 +293,97 @@ func (l *Lexer) identifier() {
 	}
 }
 
+// number scans an integer or float literal. Integers may be written in hex, octal or binary with a 0x, 0o or 0b
+// prefix, floats may have an exponent, and digits may be separated by single underscores.
 func (l *Lexer) number() {
-	tokType := NumberI
-	for isDigit(l.peek()) {
-		l.readChar()
+	if l.input[l.start] == '0' {
+		var valid func(rune) bool
+		switch l.peek() {
+		case 'x', 'X':
+			valid = isHexDigit
+		case 'o', 'O':
+			valid = isOctalDigit
+		case 'b', 'B':
+			valid = isBinaryDigit
+		}
+
+		if valid != nil {
+			l.readChar()
+			l.digits(valid)
+			l.addTokenType(NumberI)
+			return
+		}
 	}
 
+	tokType := NumberI
+	l.digits(isDigit)
+
 	if l.peek() == '.' && isDigit(l.peekNext()) {
 		tokType = NumberF
 		l.readChar()
+		l.digits(isDigit)
+	}
+
+	if l.peek() == 'e' || l.peek() == 'E' {
+		next := l.peekNext()
+		if (next == '+' || next == '-') && l.current+2 < len(l.input) {
+			next = rune(l.input[l.current+2])
+			if isDigit(next) {
+				l.readChar()
+			}
+		}
 
-		for isDigit(l.peek()) {
+		if isDigit(next) {
+			tokType = NumberF
 			l.readChar()
+			l.digits(isDigit)
 		}
 	}
 
 	l.addTokenType(tokType)
 }
 
-func (l *Lexer) string(endChar byte) {
+// digits consumes a run of characters accepted by valid. An underscore is consumed only when it is followed by
+// another valid digit.
+func (l *Lexer) digits(valid func(rune) bool) {
+	for {
+		if valid(l.peek()) || l.peek() == '_' && valid(l.peekNext()) {
+			l.readChar()
+		} else {
+			return
+		}
+	}
+}
+
+// string scans a single line string ending with endChar. The string content begins after the character at
+// l.start, which is either the opening quote or the '}' closing an interpolated expression. Escape sequences are
+// replaced with the characters they represent. If any are invalid, a BadEscape token holding the first invalid
+// sequence is added in place of the string.
+func (l *Lexer) string(endChar rune) {
+	var value bytes.Buffer
+	var invalid string
+
 	for !l.isAtEnd() && l.peek() != endChar && l.peek() != '\n' {
-		l.readChar()
+		if endChar == '"' && l.peek() == '$' && l.peekNext() == '{' {
+			l.readChar()
+			l.readChar()
+			l.interps = append(l.interps, 0)
+			l.addStringToken(Interpolation, value.String(), invalid)
+			return
+		}
+
+		escStart := l.current
+		if l.readChar() != '\\' {
+			// Copy the raw bytes so that invalid UTF-8 is preserved rather than replaced.
+			value.Write(l.input[escStart:l.current])
+			continue
+		}
+
+		esc, ok := l.escape()
+		if !ok && invalid == "" {
+			invalid = string(l.input[escStart:l.current])
+		}
+		value.WriteString(esc)
 	}
 
 	if l.isAtEnd() || l.peek() == '\n' {
@@ -229,7 +392,74 @@ func (l *Lexer) string(endChar byte) {
 	}
 
 	l.readChar() // consume last quote
-	l.addToken(NewToken(String, string(l.input[l.start+1:l.current-1]), l.file, l.line))
+	l.addStringToken(String, value.String(), invalid)
+}
+
+func (l *Lexer) addStringToken(ty TokenType, value string, invalid string) {
+	if invalid != "" {
+		l.addToken(NewToken(BadEscape, invalid, l.file, l.line))
+		return
+	}
+
+	l.addToken(NewToken(ty, value, l.file, l.line))
+}
+
+// escape reads the escape sequence following a backslash and returns the string it represents. It returns false
+// if the sequence is not valid.
+func (l *Lexer) escape() (string, bool) {
+	if l.isAtEnd() || l.peek() == '\n' {
+		return "", false
+	}
+
+	switch l.readChar() {
+	case 'n':
+		return "\n", true
+	case 't':
+		return "\t", true
+	case 'r':
+		return "\r", true
+	case '0':
+		return "\x00", true
+	case 'e':
+		return "\x1b", true
+	case '\\':
+		return "\\", true
+	case '"':
+		return "\"", true
+	case '\'':
+		return "'", true
+	case '$':
+		return "$", true
+	case 'x':
+		start := l.current
+		for l.current-start < 2 && isHexDigit(l.peek()) {
+			l.readChar()
+		}
+		if l.current-start != 2 {
+			return "", false
+		}
+		b, _ := strconv.ParseUint(string(l.input[start:l.current]), 16, 8)
+		return string([]byte{byte(b)}), true
+	case 'u':
+		if !l.match('{') {
+			return "", false
+		}
+		start := l.current
+		for isHexDigit(l.peek()) {
+			l.readChar()
+		}
+		digits := string(l.input[start:l.current])
+		if !l.match('}') || len(digits) == 0 || len(digits) > 6 {
+			return "", false
+		}
+		r, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(r)) {
+			return "", false
+		}
+		return string(rune(r)), true
+	}
+
+	return "", false
 }
 
 func (l *Lexer) multilineString() {
@@ -256,14 +486,31 @@ func (l *Lexer) isAtEnd() bool {
 
 // Helper functions.
 
-func isAlpha(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isAlpha reports whether ch may begin an identifier: any unicode letter or an underscore.
+func isAlpha(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
-func isAlphaNumeric(ch byte) bool {
-	return isAlpha(ch) || isDigit(ch)
+func isBinaryDigit(ch rune) bool {
+	return ch == '0' || ch == '1'
+}
+
+func isOctalDigit(ch rune) bool {
+	return '0' <= ch && ch <= '7'
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// isAlphaNumeric reports whether ch may continue an identifier. Unicode digits and combining marks are allowed so
+// that decomposed accented letters stay part of the identifier.
+func isAlphaNumeric(ch rune) bool {
+	return isAlpha(ch) || isDigit(ch) ||
+		ch >= utf8.RuneSelf && (unicode.IsDigit(ch) || unicode.Is(unicode.Mn, ch))
 }
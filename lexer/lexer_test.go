@@ -15,7 +15,8 @@ func TestLexer_ScanTokensCount(t *testing.T) {
 {}[]`, 7},
 		{`>=,==,<=,!= // Test 2 character tokens`, 8},
 		{`<>%:.`, 6},
-		{`~`, 2},           // Illegal and EOF
+		{`~`, 2},           // Tild and EOF
+		{`@`, 2},           // Illegal and EOF
 		{`"something"`, 2}, // Double quote String
 		{`'something'`, 2}, // Single Quote String
 		{`"something`, 2},  // Unterminated String
@@ -46,13 +47,14 @@ func TestLexer_NextToken(t *testing.T) {
 `
 	input += "`A\nMultiline\nString`\n"
 	input += `!
-,
-.
+,? ?? ?.
+. ...
 fn something true false
 if and or else for while
-class null this super return;
-do break continue import
-= +=-=%=*= /= ~/=
+class null this super return static trait with const;
+do break continue import match case default in yield
+= +=-=%=*= /= ~/= =>
+& &= | |= ^ ^= << <<= >> >>= ** **= ++ -- ~
 `
 
 	expected := []struct {
@@ -77,7 +79,11 @@ do break continue import
 		{RawString, "A\nMultiline\nString", 7},
 		{Bang, "!", 10},
 		{Comma, ",", 11},
+		{Question, "?", 11},
+		{QuestionQuestion, "??", 11},
+		{QuestionDot, "?.", 11},
 		{Dot, ".", 12},
+		{Ellipsis, "...", 12},
 		{Fn, "fn", 13},
 		{Ident, "something", 13},
 		{True, "true", 13},
@@ -93,11 +99,20 @@ do break continue import
 		{This, "this", 15},
 		{Super, "super", 15},
 		{Return, "return", 15},
+		{Static, "static", 15},
+		{Trait, "trait", 15},
+		{With, "with", 15},
+		{Const, "const", 15},
 		{Semicolon, ";", 15},
 		{Do, "do", 16},
 		{Break, "break", 16},
 		{Continue, "continue", 16},
 		{Import, "import", 16},
+		{Match, "match", 16},
+		{Case, "case", 16},
+		{Default, "default", 16},
+		{In, "in", 16},
+		{Yield, "yield", 16},
 		{Equal, "=", 17},
 		{PlusEq, "+=", 17},
 		{MinusEq, "-=", 17},
@@ -105,7 +120,23 @@ do break continue import
 		{StarEq, "*=", 17},
 		{SlashEq, "/=", 17},
 		{TildSlashEq, "~/=", 17},
-		{EOF, "", 18},
+		{Arrow, "=>", 17},
+		{Amp, "&", 18},
+		{AmpEq, "&=", 18},
+		{Pipe, "|", 18},
+		{PipeEq, "|=", 18},
+		{Caret, "^", 18},
+		{CaretEq, "^=", 18},
+		{LessLess, "<<", 18},
+		{LessLessEq, "<<=", 18},
+		{GreaterGreater, ">>", 18},
+		{GreaterGreaterEq, ">>=", 18},
+		{StarStar, "**", 18},
+		{StarStarEq, "**=", 18},
+		{PlusPlus, "++", 18},
+		{MinusMinus, "--", 18},
+		{Tild, "~", 18},
+		{EOF, "", 19},
 	}
 
 	l := New([]byte(input), "test.lpc")
@@ -130,3 +161,166 @@ do break continue import
 		}
 	}
 }
+
+func TestLexer_Interpolation(t *testing.T) {
+	input := `"a ${b} c ${ {x: "${y}"} } d" '${z}' ` + "`${raw}`"
+
+	expected := []struct {
+		ty      TokenType
+		literal string
+	}{
+		{Interpolation, "a "},
+		{Ident, "b"},
+		{Interpolation, " c "},
+		{LBrace, "{"},
+		{Ident, "x"},
+		{Colon, ":"},
+		{Interpolation, ""},
+		{Ident, "y"},
+		{String, ""},
+		{RBrace, "}"},
+		{String, " d"},
+		{String, "${z}"},
+		{RawString, "${raw}"},
+		{EOF, ""},
+	}
+
+	l := New([]byte(input), "test.lpc")
+	l.ScanTokens()
+
+	for i, expect := range expected {
+		tok := l.NextToken()
+		if tok == nil {
+			t.Fatalf("test %d: unexpected missing token. expected=%q", i, expect.ty)
+		}
+
+		if tok.Type != expect.ty {
+			t.Errorf("test %d: unexpected token. expected=%q, got=%q", i, expect.ty, tok.Type)
+		}
+
+		if tok.Lexeme != expect.literal {
+			t.Errorf("test %d: unexpected lexeme. expected=%q, got=%q", i, expect.literal, tok.Lexeme)
+		}
+	}
+}
+
+func TestLexer_Escapes(t *testing.T) {
+	tests := []struct {
+		input   string
+		ty      TokenType
+		literal string
+	}{
+		{`"line\nbreak"`, String, "line\nbreak"},
+		{`"\t\r\\\0"`, String, "\t\r\\\x00"},
+		{`"say \"hi\""`, String, `say "hi"`},
+		{`'it\'s'`, String, "it's"},
+		{`'\"'`, String, `"`},
+		{`"\x41\x7a"`, String, "Az"},
+		{`"\u{48}\u{e9}\u{1F600}"`, String, "H\u00e9\U0001F600"},
+		{`"\e[31mred\e[0m"`, String, "\x1b[31mred\x1b[0m"},
+		{`"\${not interpolated}"`, String, "${not interpolated}"},
+		{"`raw \\n`", RawString, `raw \n`},
+		{`"bad \q"`, BadEscape, `\q`},
+		{`"\x4"`, BadEscape, `\x4`},
+		{`"\u{}"`, BadEscape, `\u{}`},
+		{`"\u{110000}"`, BadEscape, `\u{110000}`},
+		{`"\u41"`, BadEscape, `\u`},
+		{"\"trailing\\\n\"", UTString, "\"trailing\\"},
+	}
+
+	for i, tt := range tests {
+		l := New([]byte(tt.input), "test.lpc")
+		l.ScanTokens()
+
+		tok := l.NextToken()
+		if tok.Type != tt.ty {
+			t.Errorf("test %d: unexpected token. expected=%q, got=%q", i+1, tt.ty, tok.Type)
+		}
+
+		if tok.Lexeme != tt.literal {
+			t.Errorf("test %d: unexpected lexeme. expected=%q, got=%q", i+1, tt.literal, tok.Lexeme)
+		}
+	}
+}
+
+func TestLexer_Unicode(t *testing.T) {
+	input := `var épée = "Lumière ☺"; // commentaire ünïcödé
+名前 naïve_1 ∑`
+
+	expected := []struct {
+		ty      TokenType
+		literal string
+		line    int
+	}{
+		{Var, "var", 1},
+		{Ident, "épée", 1},
+		{Equal, "=", 1},
+		{String, "Lumière ☺", 1},
+		{Semicolon, ";", 1},
+		{Ident, "名前", 2},
+		{Ident, "naïve_1", 2},
+		{Illegal, "∑", 2},
+		{EOF, "", 2},
+	}
+
+	l := New([]byte(input), "test.lpc")
+	l.ScanTokens()
+
+	for i, expect := range expected {
+		tok := l.NextToken()
+		if tok == nil {
+			t.Fatalf("test %d: unexpected missing token. expected=%q", i, expect.ty)
+		}
+
+		if tok.Type != expect.ty {
+			t.Errorf("test %d: unexpected token. expected=%q, got=%q", i, expect.ty, tok.Type)
+		}
+
+		if tok.Lexeme != expect.literal {
+			t.Errorf("test %d: unexpected lexeme. expected=%q, got=%q", i, expect.literal, tok.Lexeme)
+		}
+
+		if tok.Line != expect.line {
+			t.Errorf("test %d: unexpected line. expected=%d, got=%d", i, expect.line, tok.Line)
+		}
+	}
+}
+
+func TestLexer_Numbers(t *testing.T) {
+	tests := []struct {
+		input   string
+		ty      TokenType
+		literal string
+	}{
+		{"42", NumberI, "42"},
+		{"0xFF", NumberI, "0xFF"},
+		{"0Xab_cd", NumberI, "0Xab_cd"},
+		{"0o755", NumberI, "0o755"},
+		{"0b1010_1010", NumberI, "0b1010_1010"},
+		{"1_000_000", NumberI, "1_000_000"},
+		{"1__0", NumberI, "1"},
+		{"10_", NumberI, "10"},
+		{"3.25", NumberF, "3.25"},
+		{"3.", NumberI, "3"},
+		{"1e10", NumberF, "1e10"},
+		{"1.5E-3", NumberF, "1.5E-3"},
+		{"2e+8", NumberF, "2e+8"},
+		{"2e", NumberI, "2"},
+		{"2e+", NumberI, "2"},
+		{"1_0.0_1e1_0", NumberF, "1_0.0_1e1_0"},
+	}
+
+	for i, tt := range tests {
+		l := New([]byte(tt.input), "test.lpc")
+		l.ScanTokens()
+
+		tok := l.NextToken()
+		if tok.Type != tt.ty {
+			t.Errorf("test %d: unexpected token. expected=%q, got=%q", i+1, tt.ty, tok.Type)
+		}
+
+		if tok.Lexeme != tt.literal {
+			t.Errorf("test %d: unexpected lexeme. expected=%q, got=%q", i+1, tt.literal, tok.Lexeme)
+		}
+	}
+}
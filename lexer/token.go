@@ -31,44 +31,75 @@ const (
 	RBracket  TokenType = "]"
 	LParen    TokenType = "("
 	RParen    TokenType = ")"
+	Question  TokenType = "?"
 	Semicolon TokenType = ";"
 
 	// Single or two character tokens.
-	Bang      TokenType = "!"
-	BangEq    TokenType = "!="
-	Equal     TokenType = "="
-	EqualEq   TokenType = "=="
-	Greater   TokenType = ">"
-	GreaterEq TokenType = ">="
-	Less      TokenType = "<"
-	LessEq    TokenType = "<="
-	Minus     TokenType = "-"
-	MinusEq   TokenType = "-="
-	Percent   TokenType = "%"
-	PercentEq TokenType = "%="
-	Plus      TokenType = "+"
-	PlusEq    TokenType = "+="
-	Slash     TokenType = "/"
-	SlashEq   TokenType = "/="
-	Star      TokenType = "*"
-	StarEq    TokenType = "*="
-	TildSlash TokenType = "~/"
+	Amp        TokenType = "&"
+	AmpEq      TokenType = "&="
+	Arrow      TokenType = "=>"
+	Bang       TokenType = "!"
+	BangEq     TokenType = "!="
+	Caret      TokenType = "^"
+	CaretEq    TokenType = "^="
+	Equal      TokenType = "="
+	EqualEq    TokenType = "=="
+	Greater    TokenType = ">"
+	GreaterEq  TokenType = ">="
+	Less       TokenType = "<"
+	LessEq     TokenType = "<="
+	Minus      TokenType = "-"
+	MinusEq    TokenType = "-="
+	MinusMinus TokenType = "--"
+	Percent    TokenType = "%"
+	PercentEq  TokenType = "%="
+	Pipe       TokenType = "|"
+	PipeEq     TokenType = "|="
+	Plus       TokenType = "+"
+	PlusEq     TokenType = "+="
+	PlusPlus   TokenType = "++"
+	Slash      TokenType = "/"
+	SlashEq    TokenType = "/="
+	Star       TokenType = "*"
+	StarEq     TokenType = "*="
+	StarStar   TokenType = "**"
+	Tild       TokenType = "~"
+	TildSlash  TokenType = "~/"
+
+	QuestionDot      TokenType = "?."
+	QuestionQuestion TokenType = "??"
 
 	TildSlashEq TokenType = "~/="
 
+	// Three character tokens.
+	Ellipsis         TokenType = "..."
+	GreaterGreater   TokenType = ">>"
+	GreaterGreaterEq TokenType = ">>="
+	LessLess         TokenType = "<<"
+	LessLessEq       TokenType = "<<="
+	StarStarEq       TokenType = "**="
+
 	// Literals
 	Ident     TokenType = "IDENT"
 	String    TokenType = "STRING"
 	RawString TokenType = "RAW STRING"
 	UTString  TokenType = "UNTERMINATED STRING"
+	BadEscape TokenType = "INVALID ESCAPE"
 	NumberF   TokenType = "FLOAT NUMBER"
 	NumberI   TokenType = "INT NUMBER"
 
+	// Interpolation is the segment of a double quoted string which precedes an embedded "${" expression. The
+	// tokens of the expression follow it, then the remainder of the string as another Interpolation or a String.
+	Interpolation TokenType = "INTERPOLATION"
+
 	// Keywords
 	And      TokenType = "AND"
 	Break    TokenType = "BREAK"
+	Case     TokenType = "CASE"
 	Class    TokenType = "CLASS"
+	Const    TokenType = "CONST"
 	Continue TokenType = "CONTINUE"
+	Default  TokenType = "DEFAULT"
 	Do       TokenType = "DO"
 	Else     TokenType = "ELSE"
 	False    TokenType = "FALSE"
@@ -76,15 +107,21 @@ const (
 	For      TokenType = "FOR"
 	If       TokenType = "IF"
 	Import   TokenType = "IMPORT"
+	In       TokenType = "IN"
+	Match    TokenType = "MATCH"
 	Null     TokenType = "NULL"
 	Or       TokenType = "OR"
 	Print    TokenType = "PRINT"
 	Return   TokenType = "RETURN"
+	Static   TokenType = "STATIC"
 	Super    TokenType = "SUPER"
 	This     TokenType = "THIS"
+	Trait    TokenType = "TRAIT"
 	True     TokenType = "TRUE"
 	Var      TokenType = "VAR"
 	While    TokenType = "WHILE"
+	With     TokenType = "WITH"
+	Yield    TokenType = "YIELD"
 
 	Illegal TokenType = "ILLEGAL"
 	EOF     TokenType = "EOF"
@@ -93,8 +130,11 @@ const (
 var keywords = map[string]TokenType{
 	"and":      And,
 	"break":    Break,
+	"case":     Case,
 	"class":    Class,
+	"const":    Const,
 	"continue": Continue,
+	"default":  Default,
 	"do":       Do,
 	"else":     Else,
 	"false":    False,
@@ -102,13 +142,19 @@ var keywords = map[string]TokenType{
 	"for":      For,
 	"if":       If,
 	"import":   Import,
+	"in":       In,
+	"match":    Match,
 	"null":     Null,
 	"or":       Or,
 	"print":    Print,
 	"return":   Return,
+	"static":   Static,
 	"super":    Super,
 	"this":     This,
+	"trait":    Trait,
 	"true":     True,
 	"var":      Var,
 	"while":    While,
+	"with":     With,
+	"yield":    Yield,
 }
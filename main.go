@@ -10,8 +10,16 @@ import (
 )
 
 func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "test" {
+		os.Exit(runTests(os.Args[2:]))
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [script]", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [script]\n       %[1]s test [files or directories]\n       %[1]s check [files]\n", os.Args[0])
+		os.Exit(1)
 	}
 
 	runFile(os.Args[1])
@@ -27,6 +35,7 @@ func runFile(path string) {
 	err = run(data, path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 }
 
@@ -39,5 +48,14 @@ func run(input []byte, filename string) error {
 		return err
 	}
 
-	return interp.RunMain(env)
+	// Tasks spawned by main may still be running. Any which fail without being joined fail the script too.
+	err = interp.RunMain(env)
+	for _, taskErr := range interp.Wait() {
+		if err == nil {
+			err = taskErr
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%v\n", taskErr)
+	}
+	return err
 }
@@ -15,37 +15,48 @@ func main() {
 	outDir := os.Args[1]
 
 	expressions := []string{
-		"Assign   : Name *lexer.Token, Value Expr",
+		"Assign   : Name *lexer.Token, Value Expr, Type *TypeAnnotation",
 		"Binary   : Left Expr, Operator *lexer.Token, Right Expr",
 		"Boolean  : Token *lexer.Token, Value bool",
-		"Call     : Callee Expr, Paren *lexer.Token, Args []Expr",
-		"Get      : Object Expr, Name *lexer.Token",
+		"Call     : Callee Expr, Paren *lexer.Token, Args []Expr, Names []*lexer.Token",
+		"Conditional : Condition Expr, Question *lexer.Token, Then Expr, Else Expr",
+		"Get      : Object Expr, Name *lexer.Token, Optional bool",
 		"Grouping : Expression Expr",
 		"Index    : Left Expr, Operator *lexer.Token, Right Expr",
+		"Interpolation : Token *lexer.Token, Parts []Expr",
 		"List     : Values []Expr",
 		"Logical  : Left Expr, Operator *lexer.Token, Right Expr",
-		"Number   : Token *lexer.Token, Float float64, Int int",
+		"Number   : Token *lexer.Token, Float float64, Int int, Big *big.Int",
 		"Null     : Token *lexer.Token, Value interface{}",
+		"Optional : Expression Expr",
 		"Set      : Object Expr, Name *lexer.Token, Value Expr, IsIndex bool",
+		"Spawn    : Keyword *lexer.Token, Call *CallExpr",
 		"String   : Token *lexer.Token, Value string",
 		"Super    : Keyword *lexer.Token, Method *lexer.Token",
 		"This     : Keyword *lexer.Token",
 		"Unary    : Operator *lexer.Token, Right Expr",
+		"Update   : Operator *lexer.Token, Target Expr, Assign Expr, Prefix bool",
 		"Variable : Name *lexer.Token",
+		"Yield    : Keyword *lexer.Token, Value Expr",
 	}
 
 	statements := []string{
 		"Block      : Statements []Stmt",
 		"Break      : Keyword *lexer.Token",
-		"Class      : Name *lexer.Token, Super *VariableExpr, Methods []*FunctionStmt",
+		"Class      : Name *lexer.Token, Super *VariableExpr, Traits []*VariableExpr, Methods []*FunctionStmt, Getters []*FunctionStmt, Setters []*FunctionStmt, Fields []*VarStmt, StaticMethods []*FunctionStmt, StaticFields []*VarStmt",
 		"Continue   : Keyword *lexer.Token",
+		"Destructure : Keyword *lexer.Token, Names []*lexer.Token, Rest *lexer.Token, Value Expr, Const bool",
 		"Expression : Expression Expr",
-		"Function   : Name *lexer.Token, Parameters []*lexer.Token, Body []Stmt",
+		"Function   : Name *lexer.Token, Parameters []*lexer.Token, Body []Stmt, ParamTypes []*TypeAnnotation, ReturnType *TypeAnnotation, Defaults []Expr, Variadic bool, Generator bool",
 		"If         : Condition Expr, Then Stmt, Else Stmt",
+		"Match      : Keyword *lexer.Token, Subject Expr, Cases []*MatchCase",
+		"MultiAssign : Targets []Expr, Equals *lexer.Token, Values []Expr",
 		"Import     : Keyword *lexer.Token, Other Expr",
 		"For        : Keyword *lexer.Token, Initializer Stmt, Condition Expr, Body Stmt, Increment Expr",
+		"ForIn      : Keyword *lexer.Token, Name *lexer.Token, Iterable Expr, Body Stmt",
 		"Return     : Keyword *lexer.Token, Value Expr",
-		"Var        : Name *lexer.Token, Value Expr",
+		"Trait      : Name *lexer.Token, Methods []*FunctionStmt, Getters []*FunctionStmt, Setters []*FunctionStmt, Fields []*VarStmt",
+		"Var        : Name *lexer.Token, Value Expr, Type *TypeAnnotation, Const bool",
 	}
 
 	err := defineAst(outDir, expressions, statements)
@@ -63,7 +74,7 @@ func defineAst(outDir string, exprs []string, stmts []string) error {
 	defer file.Close()
 
 	checkWrite(file, "package %s\n", outDir)
-	checkWrite(file, "import \"github.com/butlermatt/glpc/lexer\"\n")
+	checkWrite(file, "import (\n\t\"math/big\"\n\n\t\"github.com/butlermatt/glpc/lexer\"\n)\n")
 	checkWrite(file,
 		`// Expr is an AST expression which returns a value of type Object or an error.
 type Expr interface {
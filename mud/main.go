@@ -4,13 +4,29 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/butlermatt/glpc/interpreter"
+	"github.com/butlermatt/glpc/object"
 	"os"
 	"strings"
+	"time"
 )
 
+// tickRate is how often the server advances the game clock.
+const tickRate = time.Second
+
+// startRoom is the room a player is in when they connect.
+const startRoom = "start"
+
 type Connection struct {
 	output chan string
 	input  chan []string
+	name   string
+	room   string
+}
+
+// command is the words of a line of input read from a connection.
+type command struct {
+	conn  *Connection
+	words []string
 }
 
 func main() {
@@ -20,8 +36,7 @@ func main() {
 	con := &Connection{output: make(chan string), input: make(chan []string)}
 	conChan <- con
 
-	inner := make(chan []string)
-	go input(inner)
+	go input(con.input)
 
 	for {
 		select {
@@ -30,8 +45,6 @@ func main() {
 			if !ok {
 				break
 			}
-		case in := <-con.input:
-			con.input <- in
 		}
 	}
 }
@@ -52,26 +65,94 @@ func input(inChan chan<- []string) {
 
 type Server struct {
 	inter *interpreter.Interpreter
+	sched *interpreter.Scheduler
 	conns []*Connection
 	cChan chan *Connection
+	cmds  chan command
 }
 
 func NewServer() *Server {
-	return &Server{inter: interpreter.New()}
+	return &Server{inter: interpreter.New(), sched: &interpreter.Scheduler{}}
 }
 
 func (s *Server) Start() chan<- *Connection {
 	s.cChan = make(chan *Connection)
+	s.cmds = make(chan command)
 	go s.run()
 	return s.cChan
 }
 
 func (s *Server) run() {
+	ticker := time.NewTicker(tickRate)
+	defer ticker.Stop()
+
 	for {
 		select {
+		case <-ticker.C:
+			s.tick()
 		case conn := <-s.cChan:
 			s.conns = append(s.conns, conn)
+			conn.name = fmt.Sprintf("player%d", len(s.conns))
+			conn.room = startRoom
 			conn.output <- "Server: Connection received.\n"
+			go s.read(conn)
+			s.emit("connect", str(conn.name))
+			s.emit("enter", str(conn.name), str(conn.room))
+		case cmd := <-s.cmds:
+			s.handle(cmd)
 		}
 	}
 }
+
+// read passes the input of a connection to the server.
+func (s *Server) read(conn *Connection) {
+	for words := range conn.input {
+		s.cmds <- command{conn: conn, words: words}
+	}
+}
+
+// handle runs a command read from a connection. Going to a room emits the enter event with the player and room, and
+// saying something emits the say event with the player and text, which is only repeated if no handler cancelled it.
+func (s *Server) handle(cmd command) {
+	conn := cmd.conn
+	switch {
+	case cmd.words[0] == "go" && len(cmd.words) == 2:
+		if s.emit("enter", str(conn.name), str(cmd.words[1])) {
+			conn.room = cmd.words[1]
+			conn.output <- "You are in " + conn.room + "."
+		}
+	case cmd.words[0] == "say" && len(cmd.words) > 1:
+		text := strings.Join(cmd.words[1:], " ")
+		if s.emit("say", str(conn.name), str(text)) {
+			conn.output <- conn.name + " says: " + text
+		}
+	default:
+		conn.output <- "Unknown command."
+	}
+}
+
+// tick advances the game clock, calling the timers scripts added with after and every, then resumes the scheduled
+// coroutines and emits the tick event with the new tick. Scripts which fail are logged, and do not stop the server.
+func (s *Server) tick() {
+	errs := s.inter.Advance(1)
+	errs = append(errs, s.sched.Tick()...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "[Tick error] %v\n", err)
+	}
+	s.emit("tick", &interpreter.Number{IsInt: true, Int: s.inter.Now()})
+}
+
+// emit sends an event to the handlers scripts have added with on, and reports whether no handler cancelled it. The
+// server emits connect and enter when a connection is received, enter and say for the commands players send, and
+// tick after each tick of the game clock. Handlers which fail are logged, and do not stop the server.
+func (s *Server) emit(event string, args ...object.Object) bool {
+	done, errs := s.inter.Emit(event, args...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "[Event error] %s: %v\n", event, err)
+	}
+	return done
+}
+
+func str(value string) object.Object {
+	return &interpreter.String{Value: value}
+}
@@ -1,6 +1,10 @@
 package object
 
-import "github.com/butlermatt/glpc/lexer"
+import (
+	"math/big"
+
+	"github.com/butlermatt/glpc/lexer"
+)
 
 // Expr is an AST expression which returns a value of type Object or an error.
 type Expr interface {
@@ -16,6 +20,7 @@ type Stmt interface {
 type AssignExpr struct {
 	Name  *lexer.Token
 	Value Expr
+	Type  *TypeAnnotation
 }
 
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
@@ -45,15 +50,30 @@ type CallExpr struct {
 	Callee Expr
 	Paren  *lexer.Token
 	Args   []Expr
+	Names  []*lexer.Token
 }
 
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
 func (c *CallExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitCallExpr(c) }
 
+// ConditionalExpr is a Expr of a Conditional
+type ConditionalExpr struct {
+	Condition Expr
+	Question  *lexer.Token
+	Then      Expr
+	Else      Expr
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (c *ConditionalExpr) Accept(visitor ExprVisitor) (Object, error) {
+	return visitor.VisitConditionalExpr(c)
+}
+
 // GetExpr is a Expr of a Get
 type GetExpr struct {
-	Object Expr
-	Name   *lexer.Token
+	Object   Expr
+	Name     *lexer.Token
+	Optional bool
 }
 
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
@@ -79,6 +99,17 @@ type IndexExpr struct {
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
 func (i *IndexExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitIndexExpr(i) }
 
+// InterpolationExpr is a Expr of a Interpolation
+type InterpolationExpr struct {
+	Token *lexer.Token
+	Parts []Expr
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (i *InterpolationExpr) Accept(visitor ExprVisitor) (Object, error) {
+	return visitor.VisitInterpolationExpr(i)
+}
+
 // ListExpr is a Expr of a List
 type ListExpr struct {
 	Values []Expr
@@ -102,6 +133,7 @@ type NumberExpr struct {
 	Token *lexer.Token
 	Float float64
 	Int   int
+	Big   *big.Int
 }
 
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
@@ -116,6 +148,16 @@ type NullExpr struct {
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
 func (n *NullExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitNullExpr(n) }
 
+// OptionalExpr is a Expr of a Optional
+type OptionalExpr struct {
+	Expression Expr
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (o *OptionalExpr) Accept(visitor ExprVisitor) (Object, error) {
+	return visitor.VisitOptionalExpr(o)
+}
+
 // SetExpr is a Expr of a Set
 type SetExpr struct {
 	Object  Expr
@@ -127,6 +169,15 @@ type SetExpr struct {
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
 func (s *SetExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitSetExpr(s) }
 
+// SpawnExpr is a Expr of a Spawn
+type SpawnExpr struct {
+	Keyword *lexer.Token
+	Call    *CallExpr
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (s *SpawnExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitSpawnExpr(s) }
+
 // StringExpr is a Expr of a String
 type StringExpr struct {
 	Token *lexer.Token
@@ -162,6 +213,17 @@ type UnaryExpr struct {
 // Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
 func (u *UnaryExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitUnaryExpr(u) }
 
+// UpdateExpr is a Expr of a Update
+type UpdateExpr struct {
+	Operator *lexer.Token
+	Target   Expr
+	Assign   Expr
+	Prefix   bool
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (u *UpdateExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitUpdateExpr(u) }
+
 // VariableExpr is a Expr of a Variable
 type VariableExpr struct {
 	Name *lexer.Token
@@ -172,25 +234,40 @@ func (v *VariableExpr) Accept(visitor ExprVisitor) (Object, error) {
 	return visitor.VisitVariableExpr(v)
 }
 
+// YieldExpr is a Expr of a Yield
+type YieldExpr struct {
+	Keyword *lexer.Token
+	Value   Expr
+}
+
+// Accept calls the correct visit method on ExprVisitor, passing a reference to itself as a value
+func (y *YieldExpr) Accept(visitor ExprVisitor) (Object, error) { return visitor.VisitYieldExpr(y) }
+
 // ExprVisitor will visit Expr objects and must receive calls to their applicable methods.
 type ExprVisitor interface {
 	VisitAssignExpr(expr *AssignExpr) (Object, error)
 	VisitBinaryExpr(expr *BinaryExpr) (Object, error)
 	VisitBooleanExpr(expr *BooleanExpr) (Object, error)
 	VisitCallExpr(expr *CallExpr) (Object, error)
+	VisitConditionalExpr(expr *ConditionalExpr) (Object, error)
 	VisitGetExpr(expr *GetExpr) (Object, error)
 	VisitGroupingExpr(expr *GroupingExpr) (Object, error)
 	VisitIndexExpr(expr *IndexExpr) (Object, error)
+	VisitInterpolationExpr(expr *InterpolationExpr) (Object, error)
 	VisitListExpr(expr *ListExpr) (Object, error)
 	VisitLogicalExpr(expr *LogicalExpr) (Object, error)
 	VisitNumberExpr(expr *NumberExpr) (Object, error)
 	VisitNullExpr(expr *NullExpr) (Object, error)
+	VisitOptionalExpr(expr *OptionalExpr) (Object, error)
 	VisitSetExpr(expr *SetExpr) (Object, error)
+	VisitSpawnExpr(expr *SpawnExpr) (Object, error)
 	VisitStringExpr(expr *StringExpr) (Object, error)
 	VisitSuperExpr(expr *SuperExpr) (Object, error)
 	VisitThisExpr(expr *ThisExpr) (Object, error)
 	VisitUnaryExpr(expr *UnaryExpr) (Object, error)
+	VisitUpdateExpr(expr *UpdateExpr) (Object, error)
 	VisitVariableExpr(expr *VariableExpr) (Object, error)
+	VisitYieldExpr(expr *YieldExpr) (Object, error)
 }
 
 // BlockStmt is a Stmt of a Block
@@ -211,9 +288,15 @@ func (b *BreakStmt) Accept(visitor StmtVisitor) error { return visitor.VisitBrea
 
 // ClassStmt is a Stmt of a Class
 type ClassStmt struct {
-	Name    *lexer.Token
-	Super   *VariableExpr
-	Methods []*FunctionStmt
+	Name          *lexer.Token
+	Super         *VariableExpr
+	Traits        []*VariableExpr
+	Methods       []*FunctionStmt
+	Getters       []*FunctionStmt
+	Setters       []*FunctionStmt
+	Fields        []*VarStmt
+	StaticMethods []*FunctionStmt
+	StaticFields  []*VarStmt
 }
 
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
@@ -227,6 +310,18 @@ type ContinueStmt struct {
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
 func (c *ContinueStmt) Accept(visitor StmtVisitor) error { return visitor.VisitContinueStmt(c) }
 
+// DestructureStmt is a Stmt of a Destructure
+type DestructureStmt struct {
+	Keyword *lexer.Token
+	Names   []*lexer.Token
+	Rest    *lexer.Token
+	Value   Expr
+	Const   bool
+}
+
+// Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
+func (d *DestructureStmt) Accept(visitor StmtVisitor) error { return visitor.VisitDestructureStmt(d) }
+
 // ExpressionStmt is a Stmt of a Expression
 type ExpressionStmt struct {
 	Expression Expr
@@ -240,6 +335,11 @@ type FunctionStmt struct {
 	Name       *lexer.Token
 	Parameters []*lexer.Token
 	Body       []Stmt
+	ParamTypes []*TypeAnnotation
+	ReturnType *TypeAnnotation
+	Defaults   []Expr
+	Variadic   bool
+	Generator  bool
 }
 
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
@@ -255,6 +355,26 @@ type IfStmt struct {
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
 func (i *IfStmt) Accept(visitor StmtVisitor) error { return visitor.VisitIfStmt(i) }
 
+// MatchStmt is a Stmt of a Match
+type MatchStmt struct {
+	Keyword *lexer.Token
+	Subject Expr
+	Cases   []*MatchCase
+}
+
+// Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
+func (m *MatchStmt) Accept(visitor StmtVisitor) error { return visitor.VisitMatchStmt(m) }
+
+// MultiAssignStmt is a Stmt of a MultiAssign
+type MultiAssignStmt struct {
+	Targets []Expr
+	Equals  *lexer.Token
+	Values  []Expr
+}
+
+// Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
+func (m *MultiAssignStmt) Accept(visitor StmtVisitor) error { return visitor.VisitMultiAssignStmt(m) }
+
 // ImportStmt is a Stmt of a Import
 type ImportStmt struct {
 	Keyword *lexer.Token
@@ -276,6 +396,17 @@ type ForStmt struct {
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
 func (f *ForStmt) Accept(visitor StmtVisitor) error { return visitor.VisitForStmt(f) }
 
+// ForInStmt is a Stmt of a ForIn
+type ForInStmt struct {
+	Keyword  *lexer.Token
+	Name     *lexer.Token
+	Iterable Expr
+	Body     Stmt
+}
+
+// Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
+func (f *ForInStmt) Accept(visitor StmtVisitor) error { return visitor.VisitForInStmt(f) }
+
 // ReturnStmt is a Stmt of a Return
 type ReturnStmt struct {
 	Keyword *lexer.Token
@@ -285,10 +416,24 @@ type ReturnStmt struct {
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
 func (r *ReturnStmt) Accept(visitor StmtVisitor) error { return visitor.VisitReturnStmt(r) }
 
+// TraitStmt is a Stmt of a Trait
+type TraitStmt struct {
+	Name    *lexer.Token
+	Methods []*FunctionStmt
+	Getters []*FunctionStmt
+	Setters []*FunctionStmt
+	Fields  []*VarStmt
+}
+
+// Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
+func (t *TraitStmt) Accept(visitor StmtVisitor) error { return visitor.VisitTraitStmt(t) }
+
 // VarStmt is a Stmt of a Var
 type VarStmt struct {
 	Name  *lexer.Token
 	Value Expr
+	Type  *TypeAnnotation
+	Const bool
 }
 
 // Accept calls the correct visit method on StmtVisitor, passing a reference to itself as a value
@@ -300,11 +445,16 @@ type StmtVisitor interface {
 	VisitBreakStmt(stmt *BreakStmt) error
 	VisitClassStmt(stmt *ClassStmt) error
 	VisitContinueStmt(stmt *ContinueStmt) error
+	VisitDestructureStmt(stmt *DestructureStmt) error
 	VisitExpressionStmt(stmt *ExpressionStmt) error
 	VisitFunctionStmt(stmt *FunctionStmt) error
 	VisitIfStmt(stmt *IfStmt) error
+	VisitMatchStmt(stmt *MatchStmt) error
+	VisitMultiAssignStmt(stmt *MultiAssignStmt) error
 	VisitImportStmt(stmt *ImportStmt) error
 	VisitForStmt(stmt *ForStmt) error
+	VisitForInStmt(stmt *ForInStmt) error
 	VisitReturnStmt(stmt *ReturnStmt) error
+	VisitTraitStmt(stmt *TraitStmt) error
 	VisitVarStmt(stmt *VarStmt) error
 }
@@ -22,6 +22,7 @@ func GetFileEnvironment(filename string) *Environment {
 type Environment struct {
 	parent *Environment
 	m      map[string]Object
+	consts map[string]bool // Names defined with DefineConst, created when the first is defined.
 }
 
 func NewEnvironment(filename string) *Environment {
@@ -43,6 +44,19 @@ func (e *Environment) Define(name *lexer.Token, value Object) error {
 	return nil
 }
 
+// DefineConst defines a constant, which cannot be assigned after it is defined.
+func (e *Environment) DefineConst(name *lexer.Token, value Object) error {
+	if err := e.Define(name, value); err != nil {
+		return err
+	}
+
+	if e.consts == nil {
+		e.consts = make(map[string]bool)
+	}
+	e.consts[name.Lexeme] = true
+	return nil
+}
+
 func (e *Environment) DefineString(name string, value Object) {
 	e.m[name] = value
 }
@@ -67,7 +81,7 @@ func (e *Environment) Get(name *lexer.Token) (Object, error) {
 func (e *Environment) GetAt(distance int, name *lexer.Token) (Object, error) {
 	env := e
 	for i := 0; i < distance; i++ {
-		env = e.parent
+		env = env.parent
 	}
 
 	return env.Get(name)
@@ -75,7 +89,11 @@ func (e *Environment) GetAt(distance int, name *lexer.Token) (Object, error) {
 
 func (e *Environment) Assign(name *lexer.Token, value Object) error {
 	if _, ok := e.m[name.Lexeme]; ok {
+		if e.consts[name.Lexeme] {
+			return NewRuntimeError(name, "Cannot assign to a constant.")
+		}
 		e.m[name.Lexeme] = value
+		return nil
 	}
 
 	if e.parent != nil {
@@ -94,8 +112,15 @@ func (e *Environment) AssignAt(distance int, name *lexer.Token, value Object) er
 	return env.Assign(name, value)
 }
 
+// Copy defines everything defined in other in e, including which names are constants.
 func (e *Environment) Copy(other *Environment) {
 	for lex, obj := range other.m {
 		e.m[lex] = obj
+		if other.consts[lex] {
+			if e.consts == nil {
+				e.consts = make(map[string]bool)
+			}
+			e.consts[lex] = true
+		}
 	}
 }
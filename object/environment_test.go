@@ -0,0 +1,59 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/butlermatt/glpc/lexer"
+)
+
+type testValue string
+
+func (v testValue) Type() Type     { return String }
+func (v testValue) String() string { return string(v) }
+
+func TestGetAt(t *testing.T) {
+	name := lexer.NewToken(lexer.Ident, "x", "", 1)
+
+	outer := NewEnclosedEnvironment(nil)
+	outer.DefineString("x", testValue("outer"))
+	middle := NewEnclosedEnvironment(outer)
+	middle.DefineString("x", testValue("middle"))
+	inner := NewEnclosedEnvironment(middle)
+
+	tests := []struct {
+		distance int
+		expected string
+	}{
+		{1, "middle"},
+		{2, "outer"},
+	}
+
+	for i, tt := range tests {
+		v, err := inner.GetAt(tt.distance, name)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i+1, err)
+		}
+		if v.String() != tt.expected {
+			t.Errorf("test %d: wrong value. expected=%q, got=%q", i+1, tt.expected, v.String())
+		}
+	}
+}
+
+func TestAssignShadowed(t *testing.T) {
+	name := lexer.NewToken(lexer.Ident, "x", "", 1)
+
+	outer := NewEnclosedEnvironment(nil)
+	outer.DefineString("x", testValue("outer"))
+	inner := NewEnclosedEnvironment(outer)
+	inner.DefineString("x", testValue("inner"))
+
+	if err := inner.Assign(name, testValue("assigned")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := inner.GetString("x").String(); v != "assigned" {
+		t.Errorf("wrong inner value. expected=%q, got=%q", "assigned", v)
+	}
+	if v := outer.GetString("x").String(); v != "outer" {
+		t.Errorf("assignment reached the shadowed variable. expected=%q, got=%q", "outer", v)
+	}
+}
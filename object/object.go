@@ -22,9 +22,14 @@ const (
 	Function
 	Instance
 	List
+	Module
 	Number
 	String
+	Trait
 	Printer
+	Generator
+	Channel
+	Task
 )
 
 func (t Type) String() string {
@@ -43,12 +48,22 @@ func (t Type) String() string {
 		return "INSTANCE"
 	case List:
 		return "LIST"
+	case Module:
+		return "MODULE"
 	case Number:
 		return "NUMBER"
 	case String:
 		return "STRING"
+	case Trait:
+		return "TRAIT"
 	case Printer:
 		return "PRINTER"
+	case Generator:
+		return "GENERATOR"
+	case Channel:
+		return "CHANNEL"
+	case Task:
+		return "TASK"
 	}
 	return ""
 }
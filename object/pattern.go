@@ -0,0 +1,38 @@
+package object
+
+import "github.com/butlermatt/glpc/lexer"
+
+// PatternKind identifies the form of a Pattern.
+type PatternKind int
+
+const (
+	// LiteralPattern matches a value equal to the literal Value.
+	LiteralPattern PatternKind = iota
+	// BindPattern matches any value, and binds it to Name unless Name is _.
+	BindPattern
+	// TypePattern matches a value of the type Type, and binds it to Name unless Name is _.
+	TypePattern
+	// ListPattern matches a list whose elements match Elements. If Rest is set, the list may have more elements,
+	// which are bound as a new list to Rest unless it is _.
+	ListPattern
+)
+
+// Pattern is a pattern of a case in a match statement. Token is the first token of the pattern, for reporting errors.
+type Pattern struct {
+	Kind     PatternKind
+	Token    *lexer.Token
+	Value    Expr
+	Name     *lexer.Token
+	Type     *TypeAnnotation
+	Elements []*Pattern
+	Rest     *lexer.Token
+}
+
+// MatchCase is a case of a match statement. The body is run if any of the Patterns match the value and the Guard, if
+// there is one, is truthy. The default case has no patterns.
+type MatchCase struct {
+	Keyword  *lexer.Token
+	Patterns []*Pattern
+	Guard    Expr
+	Body     Stmt
+}
@@ -0,0 +1,34 @@
+package object
+
+import "github.com/butlermatt/glpc/lexer"
+
+// TypeAnnotation is the optional type written after a colon following the name of a variable, field or parameter,
+// or following the parameters of a function for its return type. Name is one of the BuiltinTypes or the name of a
+// class or trait. A nullable type, written with a trailing '?', also accepts null.
+type TypeAnnotation struct {
+	Name     *lexer.Token
+	Nullable bool
+}
+
+func (t *TypeAnnotation) String() string {
+	if t.Nullable {
+		return t.Name.Lexeme + "?"
+	}
+	return t.Name.Lexeme
+}
+
+// BuiltinTypes are the type names which do not refer to a class or trait. any accepts every value, and num accepts
+// both int and float.
+var BuiltinTypes = map[string]bool{
+	"any":       true,
+	"bool":      true,
+	"channel":   true,
+	"float":     true,
+	"fn":        true,
+	"generator": true,
+	"int":       true,
+	"list":      true,
+	"num":       true,
+	"string":    true,
+	"task":      true,
+}
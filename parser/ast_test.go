@@ -55,7 +55,14 @@ func (p *AstPrinter) VisitBooleanExpr(expr *object.BooleanExpr) (object.Object,
 	return printerObj{value: fmt.Sprintf("%t", expr.Value)}, nil
 }
 
+func (p *AstPrinter) VisitConditionalExpr(expr *object.ConditionalExpr) (object.Object, error) {
+	return p.parenthesize("?:", expr.Condition, expr.Then, expr.Else), nil
+}
+
 func (p *AstPrinter) VisitGetExpr(expr *object.GetExpr) (object.Object, error) {
+	if expr.Optional {
+		return p.parenthesize("?."+expr.Name.Lexeme, expr.Object), nil
+	}
 	return p.parenthesize("."+expr.Name.Lexeme, expr.Object), nil
 }
 
@@ -67,6 +74,10 @@ func (p *AstPrinter) VisitIndexExpr(expr *object.IndexExpr) (object.Object, erro
 	return p.parenthesize("[]", expr.Left, expr.Right), nil
 }
 
+func (p *AstPrinter) VisitInterpolationExpr(expr *object.InterpolationExpr) (object.Object, error) {
+	return p.parenthesize("interp", expr.Parts...), nil
+}
+
 func (p *AstPrinter) VisitListExpr(expr *object.ListExpr) (object.Object, error) {
 	var b bytes.Buffer
 
@@ -91,10 +102,18 @@ func (p *AstPrinter) VisitNullExpr(expr *object.NullExpr) (object.Object, error)
 	return printerObj{"null"}, nil
 }
 
+func (p *AstPrinter) VisitOptionalExpr(expr *object.OptionalExpr) (object.Object, error) {
+	return p.parenthesize("optional", expr.Expression), nil
+}
+
 func (p *AstPrinter) VisitSetExpr(expr *object.SetExpr) (object.Object, error) {
 	return p.parenthesize(expr.Name.Lexeme, expr.Object, expr.Value), nil
 }
 
+func (p *AstPrinter) VisitSpawnExpr(expr *object.SpawnExpr) (object.Object, error) {
+	return p.parenthesize("spawn", expr.Call), nil
+}
+
 func (p *AstPrinter) VisitStringExpr(expr *object.StringExpr) (object.Object, error) {
 	return printerObj{expr.Value}, nil
 }
@@ -107,10 +126,21 @@ func (p *AstPrinter) VisitThisExpr(expr *object.ThisExpr) (object.Object, error)
 	return printerObj{value: "this"}, nil
 }
 
+func (p *AstPrinter) VisitUpdateExpr(expr *object.UpdateExpr) (object.Object, error) {
+	if expr.Prefix {
+		return p.parenthesize("pre"+expr.Operator.Lexeme, expr.Target), nil
+	}
+	return p.parenthesize("post"+expr.Operator.Lexeme, expr.Target), nil
+}
+
 func (p *AstPrinter) VisitVariableExpr(expr *object.VariableExpr) (object.Object, error) {
 	return printerObj{value: expr.Name.Lexeme}, nil
 }
 
+func (p *AstPrinter) VisitYieldExpr(expr *object.YieldExpr) (object.Object, error) {
+	return p.parenthesize("yield", expr.Value), nil
+}
+
 func (p *AstPrinter) parenthesize(name string, exprs ...object.Expr) printerObj {
 	var b bytes.Buffer
 
@@ -147,6 +177,26 @@ func TestASTGrouping(t *testing.T) {
 		{"var x = true or false;", "(or true false)"},
 		{"var x = a[b + c];", "([] a (+ b c))"},
 		{"var x = a.b;", "(.b a)"},
+		{"var x = a.b[0];", "([] (.b a) 0)"},
+		{"var x = f()[1][2].c;", "(.c ([] ([] (call f) 1) 2))"},
+		{`var x = "${a}+${b * c}";`, "(interp a + (* b c))"},
+		{"var x = a ? b : c ? d : e;", "(?: a b (?: c d e))"},
+		{"var x = a or b ? c + 1 : d;", "(?: (or a b) (+ c 1) d)"},
+		{"var x = a ?? b ?? c or d;", "(?? (?? a b) (or c d))"},
+		{"var x = a ?? b ? c : d;", "(?: (?? a b) c d)"},
+		{"var x = a?.b.c;", "(optional (.c (?.b a)))"},
+		{"var x = a?.b(1)?.c;", "(optional (?.c (call (?.b a) 1)))"},
+		{"var x = a | b ^ c & d << 1 + 2;", "(| a (^ b (& c (<< d (+ 1 2)))))"},
+		{"var x = flags & MASK == 0;", "(== (& flags MASK) 0)"},
+		{"var x = a >> 2 < b | c;", "(< (>> a 2) (| b c))"},
+		{"var x = -2 ** 2;", "(- (** 2 2))"},
+		{"var x = 2 ** 3 ** 2;", "(** 2 (** 3 2))"},
+		{"var x = 2 ** -a * 3;", "(* (** 2 (- a)) 3)"},
+		{"var x = ~a & b;", "(& (~ a) b)"},
+		{"var x = a.b++ + --c[0];", "(+ (post++ (.b a)) (pre-- ([] c 0)))"},
+		{"var x = a <<= 2;", "(= a (<< a 2))"},
+		{"var x = a **= 2;", "(= a (** a 2))"},
+		{"var x = spawn f(a)(b) ?? c;", "(?? (spawn (call (call f a) b)) c)"},
 	}
 
 	for i, tt := range tests {
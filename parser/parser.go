@@ -2,7 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/butlermatt/glpc/lexer"
 	"github.com/butlermatt/glpc/object"
@@ -60,6 +62,8 @@ type Parser struct {
 	errLen   int
 	inLoop   bool
 	curFn    functionType
+	yields   bool // Whether the body of the function being parsed contains a yield.
+	inParams bool // Whether the parameter defaults of a function are being parsed.
 	curClass classType
 	resolve  *Resolver
 }
@@ -169,9 +173,11 @@ func (p *Parser) declaration() object.Stmt {
 	switch {
 	case p.match(lexer.Class):
 		stmt = p.classDeclaration()
+	case p.match(lexer.Trait):
+		stmt = p.traitDeclaration()
 	case p.match(lexer.Fn):
 		stmt = p.function(ftFunc)
-	case p.match(lexer.Var):
+	case p.match(lexer.Var, lexer.Const):
 		stmt = p.varDeclaration()
 	case p.match(lexer.Import):
 		p.addError(p.prevTok, "Import statements must appear at the beginning of a file.")
@@ -209,55 +215,205 @@ func (p *Parser) classDeclaration() object.Stmt {
 			p.curClass = prevClass
 			return nil
 		}
-		p.curClass = ctSubclass
 		super = &object.VariableExpr{Name: p.prevTok}
 		p.resolve.Local(super, p.prevTok)
+	}
+
+	var traits []*object.VariableExpr
+	if p.match(lexer.With) {
+		for {
+			if !p.consume(lexer.Ident, "Expect trait name.") {
+				p.curClass = prevClass
+				return nil
+			}
+			trait := &object.VariableExpr{Name: p.prevTok}
+			p.resolve.Local(trait, p.prevTok)
+			traits = append(traits, trait)
+			if !p.match(lexer.Comma) {
+				break
+			}
+		}
+	}
+
+	// Traits are mixed in between the class and its superclass, so super may be used with either.
+	if super != nil || len(traits) > 0 {
+		p.curClass = ctSubclass
 		p.resolve.Begin()
 		scope := p.resolve.Peek()
 		scope["super"] = true
 	}
 
-	if !p.consume(lexer.LBrace, "Expect '{' before class body.") {
-		if p.curClass == ctSubclass {
-			p.resolve.End()
-		}
-		p.curClass = prevClass
+	cs := &object.ClassStmt{Name: name, Super: super, Traits: traits}
+	ok := p.classBody(cs, "class")
+
+	if p.curClass == ctSubclass {
+		p.resolve.End()
+	}
+	p.curClass = prevClass
+	if !ok {
 		return nil
 	}
+	return cs
+}
+
+// traitDeclaration parses a trait, whose body may hold anything a class body can except static members and an
+// initializer. super within a trait refers to the next class or trait after it in the class it is mixed into.
+func (p *Parser) traitDeclaration() object.Stmt {
+	if !p.consume(lexer.Ident, "Expect trait name.") {
+		return nil
+	}
+
+	prevClass := p.curClass
+	name := p.prevTok
+	p.resolve.Declare(name)
+	p.resolve.Define(name)
+
+	p.curClass = ctSubclass
+	p.resolve.Begin()
+	scope := p.resolve.Peek()
+	scope["super"] = true
+
+	cs := &object.ClassStmt{Name: name}
+	ok := p.classBody(cs, "trait")
+
+	p.resolve.End()
+	p.curClass = prevClass
+	if !ok {
+		return nil
+	}
+
+	if len(cs.StaticMethods) > 0 || len(cs.StaticFields) > 0 {
+		p.addMemberError(name, "Traits cannot have static members.")
+	}
+	for _, m := range cs.Methods {
+		if m.Name.Lexeme == "init" {
+			p.addMemberError(m.Name, "Traits cannot have initializers.")
+		}
+	}
+
+	return &object.TraitStmt{Name: name, Methods: cs.Methods, Getters: cs.Getters, Setters: cs.Setters, Fields: cs.Fields}
+}
+
+// classBody parses the members between the braces of a class or trait into cs, in a new scope defining this. kind
+// names the declaration in error messages.
+func (p *Parser) classBody(cs *object.ClassStmt, kind string) bool {
+	if !p.consume(lexer.LBrace, "Expect '{' before "+kind+" body.") {
+		return false
+	}
 
 	p.resolve.Begin()
 	scope := p.resolve.Peek()
 	scope["this"] = true
 
-	var methods []*object.FunctionStmt
 	for !p.check(lexer.RBrace) && p.curTok.Type != lexer.EOF {
+		if !p.classMember(cs) {
+			p.resolve.End()
+			return false
+		}
+	}
+
+	ok := p.consume(lexer.RBrace, "Expect '}' after "+kind+" body.")
+	p.resolve.End()
+	return ok
+}
+
+// classMember parses one member of a class body into cs: a method, a get or set accessor, or a var field. Methods
+// and fields may be marked static. It returns false if the member could not be parsed.
+func (p *Parser) classMember(cs *object.ClassStmt) bool {
+	isStatic := p.match(lexer.Static)
+
+	if p.match(lexer.Var) {
+		field := p.fieldDeclaration()
+		if field == nil {
+			return false
+		}
+		if isStatic {
+			cs.StaticFields = append(cs.StaticFields, field)
+		} else {
+			cs.Fields = append(cs.Fields, field)
+		}
+		return true
+	}
+
+	// get and set are only accessor keywords when followed by the property name.
+	next := p.l.PeekToken()
+	if p.check(lexer.Ident) && (p.curTok.Lexeme == "get" || p.curTok.Lexeme == "set") && next != nil && next.Type == lexer.Ident {
+		p.nextToken()
+		accessor := p.prevTok
+		if isStatic {
+			p.addMemberError(accessor, "Accessors cannot be static.")
+		}
+
 		f := p.function(ftMethod)
 		if f == nil {
-			p.resolve.End()
-			if p.curClass == ctSubclass {
-				p.resolve.End()
+			return false
+		}
+		fn := f.(*object.FunctionStmt)
+		if accessor.Lexeme == "get" {
+			if len(fn.Parameters) != 0 {
+				p.addMemberError(fn.Name, "A getter cannot have parameters.")
 			}
-			p.curClass = prevClass
-			return nil
+			cs.Getters = append(cs.Getters, fn)
+		} else {
+			if len(fn.Parameters) != 1 {
+				p.addMemberError(fn.Name, "A setter must have exactly one parameter.")
+			}
+			cs.Setters = append(cs.Setters, fn)
 		}
-		methods = append(methods, f.(*object.FunctionStmt))
+		return true
 	}
 
-	if !p.consume(lexer.RBrace, "Expect '}' after class body.") {
-		p.resolve.End()
-		if p.curClass == ctSubclass {
-			p.resolve.End()
+	f := p.function(ftMethod)
+	if f == nil {
+		return false
+	}
+	fn := f.(*object.FunctionStmt)
+	if isStatic {
+		if fn.Name.Lexeme == "init" {
+			p.addMemberError(fn.Name, "An initializer cannot be static.")
 		}
-		p.curClass = prevClass
+		cs.StaticMethods = append(cs.StaticMethods, fn)
+	} else {
+		cs.Methods = append(cs.Methods, fn)
+	}
+	return true
+}
+
+// addMemberError reports a class member, parameter, argument or assignment which was parsed correctly but is not
+// allowed. Parsing is still in step with the tokens, so the error is marked as handled to stop the next declaration
+// synchronizing.
+func (p *Parser) addMemberError(token *lexer.Token, msg string) {
+	p.addError(token, msg)
+	p.errLen = len(p.errors)
+}
+
+// fieldDeclaration parses a field declared in a class body. Unlike a variable its name is not declared in the
+// enclosing scope, as it becomes a property of each instance, or of the class when static.
+func (p *Parser) fieldDeclaration() *object.VarStmt {
+	if !p.consume(lexer.Ident, "Expect field name.") {
 		return nil
 	}
+	name := p.prevTok
 
-	p.resolve.End()
-	if p.curClass == ctSubclass {
-		p.resolve.End()
+	var typ *object.TypeAnnotation
+	if p.match(lexer.Colon) {
+		if typ = p.typeAnnotation(); typ == nil {
+			return nil
+		}
 	}
-	p.curClass = prevClass
-	return &object.ClassStmt{Name: name, Super: super, Methods: methods}
+
+	var init object.Expr
+	if p.match(lexer.Equal) {
+		init = p.expression()
+		if init == nil {
+			return nil
+		}
+	}
+
+	if !p.consume(lexer.Semicolon, "Expect ';' after field declaration.") {
+		return nil
+	}
+	return &object.VarStmt{Name: name, Value: init, Type: typ}
 }
 
 func (p *Parser) function(fnType functionType) object.Stmt {
@@ -281,30 +437,72 @@ func (p *Parser) function(fnType functionType) object.Stmt {
 
 	p.resolve.Begin()
 	var params []*lexer.Token
+	var paramTypes []*object.TypeAnnotation
+	var defaults []object.Expr
+	typed, hasDefaults, variadic := false, false, false
 	if !p.check(lexer.RParen) {
-		if !p.consume(lexer.Ident, "Expect parameter name.") {
-			p.resolve.End()
-			p.curFn = prevFn
-			return nil
-		}
-
-		p.resolve.Define(p.prevTok)
-		p.resolve.Declare(p.prevTok)
-		params = append(params, p.prevTok)
-		for p.match(lexer.Comma) {
+		for {
 			if len(params) > 32 {
 				p.addError(p.curTok, "Cannot have more than 32 parameters.")
 			}
+			if variadic {
+				p.addMemberError(p.prevTok, "Rest parameter must be the last parameter.")
+			}
+			variadic = p.match(lexer.Ellipsis)
 			if !p.consume(lexer.Ident, "Expect parameter name.") {
 				p.resolve.End()
 				p.curFn = prevFn
 				return nil
 			}
-			p.resolve.Define(p.prevTok)
-			p.resolve.Declare(p.prevTok)
-			params = append(params, p.prevTok)
+			param := p.prevTok
+			p.resolve.Define(param)
+			p.resolve.Declare(param)
+			params = append(params, param)
+
+			var typ *object.TypeAnnotation
+			if p.match(lexer.Colon) {
+				if typ = p.typeAnnotation(); typ == nil {
+					p.resolve.End()
+					p.curFn = prevFn
+					return nil
+				}
+				p.resolve.SetType(param, typ)
+				typed = true
+			}
+			paramTypes = append(paramTypes, typ)
+
+			// Defaults are evaluated when the function is called, after the parameters before them are bound.
+			var def object.Expr
+			if p.match(lexer.Equal) {
+				if variadic {
+					p.addMemberError(p.prevTok, "Rest parameter cannot have a default value.")
+				}
+				p.inParams = true
+				def = p.expression()
+				p.inParams = false
+				if def == nil {
+					p.resolve.End()
+					p.curFn = prevFn
+					return nil
+				}
+				hasDefaults = true
+			} else if hasDefaults && !variadic {
+				p.addMemberError(param, "Parameter without a default value cannot follow one with a default value.")
+			}
+			defaults = append(defaults, def)
+
+			if !p.match(lexer.Comma) {
+				break
+			}
 		}
 	}
+	// ParamTypes and Defaults are only kept when at least one parameter has a type or default.
+	if !typed {
+		paramTypes = nil
+	}
+	if !hasDefaults {
+		defaults = nil
+	}
 
 	if !p.consume(lexer.RParen, "Expect ')' after parameters.") {
 		p.resolve.End()
@@ -312,39 +510,146 @@ func (p *Parser) function(fnType functionType) object.Stmt {
 		return nil
 	}
 
+	var returnType *object.TypeAnnotation
+	if p.match(lexer.Colon) {
+		if returnType = p.typeAnnotation(); returnType == nil {
+			p.resolve.End()
+			p.curFn = prevFn
+			return nil
+		}
+	}
+
 	if !p.consume(lexer.LBrace, "Expect '{' before "+fnType.String()+" body.") {
 		p.resolve.End()
 		p.curFn = prevFn
 		return nil
 	}
 
+	// A function whose body yields is a generator.
+	prevYields := p.yields
+	p.yields = false
 	body := p.block()
+	generator := p.yields
+	p.yields = prevYields
 
 	p.resolve.End()
 	p.curFn = prevFn
-	return &object.FunctionStmt{Name: name, Parameters: params, Body: body}
+	return &object.FunctionStmt{Name: name, Parameters: params, Body: body, ParamTypes: paramTypes, ReturnType: returnType, Defaults: defaults, Variadic: variadic, Generator: generator}
 
 }
 
+// varDeclaration parses a variable declared with var, or a constant declared with const. A constant must have an
+// initializer and cannot be assigned again.
 func (p *Parser) varDeclaration() object.Stmt {
+	isConst := p.prevTok.Type == lexer.Const
+	if p.match(lexer.LBracket, lexer.LBrace) {
+		return p.destructure(isConst)
+	}
 	if !p.consume(lexer.Ident, "Expect variable name.") {
 		return nil
 	}
 
 	name := p.prevTok
 	p.resolve.Declare(name)
+	if isConst {
+		p.resolve.SetConst(name)
+	}
+
+	var typ *object.TypeAnnotation
+	if p.match(lexer.Colon) {
+		if typ = p.typeAnnotation(); typ == nil {
+			p.resolve.Define(name)
+			return nil
+		}
+		p.resolve.SetType(name, typ)
+	}
 
 	var init object.Expr
 	if p.match(lexer.Equal) {
 		init = p.expression()
 		if init == nil {
+			p.resolve.Define(name)
 			return nil
 		}
+	} else if isConst {
+		p.addError(p.curTok, "Expect '=' after constant name.")
+		p.resolve.Define(name)
+		return nil
 	}
 
 	p.resolve.Define(name)
 	p.consume(lexer.Semicolon, "Expect ';' after variable declaration.")
-	return &object.VarStmt{Name: name, Value: init}
+	return &object.VarStmt{Name: name, Value: init, Type: typ, Const: isConst}
+}
+
+// destructure parses a declaration of several variables following the '[' or '{' which begins it. With brackets the
+// variables are given the elements of a list, the last of which may be '...' and a name to hold the rest. With braces
+// each is given the property of the same name.
+func (p *Parser) destructure(isConst bool) object.Stmt {
+	ds := &object.DestructureStmt{Keyword: p.prevTok, Const: isConst}
+	closing, closeName := lexer.RBracket, "]"
+	if ds.Keyword.Type == lexer.LBrace {
+		closing, closeName = lexer.RBrace, "}"
+	}
+
+	for !p.check(closing) {
+		if closing == lexer.RBracket && p.match(lexer.Ellipsis) {
+			if !p.consume(lexer.Ident, "Expect name after '...'.") {
+				return nil
+			}
+			ds.Rest = p.prevTok
+			break
+		}
+		if !p.consume(lexer.Ident, "Expect variable name.") {
+			return nil
+		}
+		ds.Names = append(ds.Names, p.prevTok)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	if !p.consume(closing, "Expect '"+closeName+"' after variable names.") {
+		return nil
+	}
+
+	names := ds.Names
+	if ds.Rest != nil {
+		names = append(names[:len(names):len(names)], ds.Rest)
+	}
+	if len(names) == 0 {
+		p.addMemberError(ds.Keyword, "Expect variable name.")
+	}
+	for _, name := range names {
+		p.resolve.Declare(name)
+		if isConst {
+			p.resolve.SetConst(name)
+		}
+	}
+
+	if p.consume(lexer.Equal, "Expect '=' after variable names.") {
+		ds.Value = p.expression()
+	}
+	for _, name := range names {
+		p.resolve.Define(name)
+	}
+	if ds.Value == nil {
+		return nil
+	}
+
+	p.consume(lexer.Semicolon, "Expect ';' after variable declaration.")
+	return ds
+}
+
+// typeAnnotation parses the type name following the ':' of an annotation, and the '?' which makes it nullable.
+func (p *Parser) typeAnnotation() *object.TypeAnnotation {
+	// fn is a keyword, but is also the name of the type of functions.
+	if !p.match(lexer.Fn) && !p.consume(lexer.Ident, "Expect type name.") {
+		return nil
+	}
+
+	t := &object.TypeAnnotation{Name: p.prevTok}
+	t.Nullable = p.match(lexer.Question)
+	return t
 }
 
 func (p *Parser) statement() object.Stmt {
@@ -364,6 +669,8 @@ func (p *Parser) statement() object.Stmt {
 		return p.forStatement()
 	case p.match(lexer.If):
 		return p.ifStatement()
+	case p.match(lexer.Match):
+		return p.matchStatement()
 	case p.match(lexer.Return):
 		return p.returnStatement()
 	case p.match(lexer.While):
@@ -456,6 +763,10 @@ func (p *Parser) forStatement() object.Stmt {
 	if p.match(lexer.Semicolon) {
 		init = nil
 	} else if p.match(lexer.Var) {
+		if p.check(lexer.Ident) && p.l.PeekToken().Type == lexer.In {
+			p.resolve.End()
+			return p.forInStatement(keyword)
+		}
 		init = p.varDeclaration()
 	} else {
 		init = p.expressionStatement()
@@ -488,6 +799,30 @@ func (p *Parser) forStatement() object.Stmt {
 	return &object.ForStmt{Keyword: keyword, Initializer: init, Condition: cond, Body: body, Increment: increment}
 }
 
+// forInStatement parses the rest of a loop over the elements of a list, the characters of a string or the values of
+// a generator, after its 'var'. The variable is declared in a new scope for each element.
+func (p *Parser) forInStatement(keyword *lexer.Token) object.Stmt {
+	p.nextToken()
+	name := p.prevTok
+	p.nextToken()
+
+	iterable := p.expression()
+	if !p.consume(lexer.RParen, "Expect ')' after for-in clause.") {
+		return nil
+	}
+
+	p.resolve.Begin()
+	p.resolve.Declare(name)
+	p.resolve.Define(name)
+	loopCond := p.inLoop
+	p.inLoop = true
+	body := p.statement()
+	p.inLoop = loopCond
+	p.resolve.End()
+
+	return &object.ForInStmt{Keyword: keyword, Name: name, Iterable: iterable, Body: body}
+}
+
 func (p *Parser) ifStatement() object.Stmt {
 	if !p.consume(lexer.LParen, "Expect '(' after 'if'.") {
 		return nil
@@ -502,11 +837,203 @@ func (p *Parser) ifStatement() object.Stmt {
 	thenBranch := p.statement()
 	var elseBranch object.Stmt
 
-	if p.match(lexer.Else) {
-		elseBranch = p.statement()
+	if p.match(lexer.Else) {
+		elseBranch = p.statement()
+	}
+
+	return &object.IfStmt{Condition: cond, Then: thenBranch, Else: elseBranch}
+}
+
+// matchStatement parses a match statement. The default case, if there is one, must be the last.
+func (p *Parser) matchStatement() object.Stmt {
+	keyword := p.prevTok
+	if !p.consume(lexer.LParen, "Expect '(' after 'match'.") {
+		return nil
+	}
+
+	subject := p.expression()
+	if subject == nil {
+		return nil
+	}
+	if !p.consume(lexer.RParen, "Expect ')' after match value.") {
+		return nil
+	}
+	if !p.consume(lexer.LBrace, "Expect '{' before match cases.") {
+		return nil
+	}
+
+	ms := &object.MatchStmt{Keyword: keyword, Subject: subject}
+	var def *lexer.Token
+	for !p.check(lexer.RBrace) && p.curTok.Type != lexer.EOF {
+		mc := p.matchCase()
+		if mc == nil {
+			return nil
+		}
+		if def != nil {
+			p.addMemberError(mc.Keyword, "The default case must be the last case.")
+		}
+		if mc.Patterns == nil {
+			def = mc.Keyword
+		}
+		ms.Cases = append(ms.Cases, mc)
+	}
+
+	if !p.consume(lexer.RBrace, "Expect '}' after match cases.") {
+		return nil
+	}
+	return ms
+}
+
+// matchCase parses a case or the default case of a match statement. Each case has its own scope, which holds the
+// variables bound by its patterns and is seen by its guard and body.
+func (p *Parser) matchCase() *object.MatchCase {
+	if !p.match(lexer.Case, lexer.Default) {
+		p.addError(p.curTok, "Expect 'case' or 'default'.")
+		return nil
+	}
+	mc := &object.MatchCase{Keyword: p.prevTok}
+
+	p.resolve.Begin()
+	defer p.resolve.End()
+
+	if mc.Keyword.Type == lexer.Case {
+		for {
+			pat := p.pattern()
+			if pat == nil {
+				return nil
+			}
+			mc.Patterns = append(mc.Patterns, pat)
+			if !p.match(lexer.Comma) {
+				break
+			}
+		}
+
+		if len(mc.Patterns) > 1 {
+			for _, pat := range mc.Patterns {
+				if name := boundName(pat); name != nil {
+					p.addMemberError(name, "A case with several patterns cannot bind variables.")
+					break
+				}
+			}
+		}
+
+		if p.match(lexer.If) {
+			if mc.Guard = p.expression(); mc.Guard == nil {
+				return nil
+			}
+		}
+	}
+
+	if !p.consume(lexer.Arrow, "Expect '=>' after '"+mc.Keyword.Lexeme+"'.") {
+		return nil
+	}
+	if mc.Body = p.statement(); mc.Body == nil {
+		return nil
+	}
+	return mc
+}
+
+// pattern parses a pattern of a match case, declaring the variables it binds in the current scope. A name followed
+// by another name is a type pattern, and a name alone binds any value.
+func (p *Parser) pattern() *object.Pattern {
+	tok := p.curTok
+	next := p.l.PeekToken()
+
+	switch {
+	case p.match(lexer.LBracket):
+		return p.listPattern(tok)
+	case p.check(lexer.Fn) || p.check(lexer.Ident) && next != nil && (next.Type == lexer.Ident || next.Type == lexer.Question):
+		typ := p.typeAnnotation()
+		if typ == nil || !p.consume(lexer.Ident, "Expect variable name after type in pattern.") {
+			return nil
+		}
+		p.bind(p.prevTok)
+		return &object.Pattern{Kind: object.TypePattern, Token: tok, Type: typ, Name: p.prevTok}
+	case p.match(lexer.Ident):
+		p.bind(p.prevTok)
+		return &object.Pattern{Kind: object.BindPattern, Token: tok, Name: p.prevTok}
+	case p.match(lexer.Minus):
+		oper := p.prevTok
+		if !p.match(lexer.NumberF, lexer.NumberI) {
+			p.addError(p.curTok, "Expect number after '-' in pattern.")
+			return nil
+		}
+		num := p.parseNumber()
+		if num == nil {
+			return nil
+		}
+		return &object.Pattern{Kind: object.LiteralPattern, Token: tok, Value: &object.UnaryExpr{Operator: oper, Right: num}}
+	case p.check(lexer.False) || p.check(lexer.True) || p.check(lexer.Null) || p.check(lexer.NumberF) ||
+		p.check(lexer.NumberI) || p.check(lexer.String) || p.check(lexer.RawString):
+		value := p.primary()
+		if value == nil {
+			return nil
+		}
+		return &object.Pattern{Kind: object.LiteralPattern, Token: tok, Value: value}
+	}
+
+	p.addError(p.curTok, "Expect pattern.")
+	return nil
+}
+
+// listPattern parses the elements of a list pattern following its '['. The last element may be '...' and a name,
+// which is bound to the elements the others did not match.
+func (p *Parser) listPattern(tok *lexer.Token) *object.Pattern {
+	pat := &object.Pattern{Kind: object.ListPattern, Token: tok}
+
+	for !p.check(lexer.RBracket) {
+		if p.match(lexer.Ellipsis) {
+			if !p.consume(lexer.Ident, "Expect name after '...'.") {
+				return nil
+			}
+			pat.Rest = p.prevTok
+			p.bind(pat.Rest)
+			if !p.check(lexer.RBracket) {
+				p.addError(p.curTok, "The rest pattern must be the last element of a list pattern.")
+				return nil
+			}
+			break
+		}
+
+		elem := p.pattern()
+		if elem == nil {
+			return nil
+		}
+		pat.Elements = append(pat.Elements, elem)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+
+	if !p.consume(lexer.RBracket, "Expect ']' after list pattern.") {
+		return nil
+	}
+	return pat
+}
+
+// bind declares a variable bound by a pattern, unless it is the wildcard _.
+func (p *Parser) bind(name *lexer.Token) {
+	if name.Lexeme == "_" {
+		return
 	}
+	p.resolve.Declare(name)
+	p.resolve.Define(name)
+}
 
-	return &object.IfStmt{Condition: cond, Then: thenBranch, Else: elseBranch}
+// boundName returns the name of the first variable bound by pat, or nil if it binds none.
+func boundName(pat *object.Pattern) *lexer.Token {
+	if pat.Name != nil && pat.Name.Lexeme != "_" {
+		return pat.Name
+	}
+	if pat.Rest != nil && pat.Rest.Lexeme != "_" {
+		return pat.Rest
+	}
+	for _, elem := range pat.Elements {
+		if name := boundName(elem); name != nil {
+			return name
+		}
+	}
+	return nil
 }
 
 func (p *Parser) returnStatement() object.Stmt {
@@ -516,6 +1043,14 @@ func (p *Parser) returnStatement() object.Stmt {
 
 	if !p.check(lexer.Semicolon) {
 		value = p.expression()
+		// Several values are returned together in a list.
+		if p.check(lexer.Comma) {
+			values := []object.Expr{value}
+			for p.match(lexer.Comma) {
+				values = append(values, p.expression())
+			}
+			value = &object.ListExpr{Values: values}
+		}
 	} else {
 		value = &object.NullExpr{Token: lexer.NewToken(lexer.Null, "null", keyword.Filename, keyword.Line), Value: nil}
 	}
@@ -556,39 +1091,90 @@ func (p *Parser) whileStatement() object.Stmt {
 
 func (p *Parser) expressionStatement() object.Stmt {
 	expr := p.expression()
+	if expr != nil && p.match(lexer.Comma) {
+		return p.multiAssign(expr)
+	}
 	if !p.consume(lexer.Semicolon, "Expect ';' after value.") {
 		return nil
 	}
 	return &object.ExpressionStmt{Expression: expr}
 }
 
+// multiAssign parses an assignment to several targets at once, such as a, b = b, a, following the comma after the
+// first target. There must be a value for each target, or a single value holding a list to unpack.
+func (p *Parser) multiAssign(first object.Expr) object.Stmt {
+	exprs := []object.Expr{first}
+	for {
+		target := p.call()
+		if target == nil {
+			return nil
+		}
+		exprs = append(exprs, target)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+
+	if !p.consume(lexer.Equal, "Expect '=' after assignment targets.") {
+		return nil
+	}
+	ma := &object.MultiAssignStmt{Equals: p.prevTok}
+
+	valid := true
+	for _, expr := range exprs {
+		target := p.assignTarget(expr, nil)
+		if target == nil {
+			p.addMemberError(ma.Equals, "Invalid assignment target.")
+			valid = false
+		}
+		ma.Targets = append(ma.Targets, target)
+	}
+
+	for {
+		value := p.expression()
+		if value == nil {
+			return nil
+		}
+		ma.Values = append(ma.Values, value)
+		if !p.match(lexer.Comma) {
+			break
+		}
+	}
+	if len(ma.Values) > 1 && len(ma.Values) != len(ma.Targets) {
+		p.addMemberError(ma.Equals, fmt.Sprintf("Expected %d values but got %d.", len(ma.Targets), len(ma.Values)))
+	}
+
+	if !p.consume(lexer.Semicolon, "Expect ';' after value.") || !valid {
+		return nil
+	}
+	return ma
+}
+
 func (p *Parser) expression() object.Expr {
 	return p.assignment()
 }
 
 func (p *Parser) assignment() object.Expr {
-	expr := p.or()
+	if p.match(lexer.Yield) {
+		return p.yieldExpr()
+	}
+
+	expr := p.conditional()
 
 	if p.match(lexer.Equal) {
 		equals := p.prevTok
 		value := p.assignment()
 
-		switch e := expr.(type) {
-		case *object.VariableExpr:
-			ae := &object.AssignExpr{Name: e.Name, Value: value}
-			p.resolve.Local(ae, ae.Name)
-			return ae
-		case *object.GetExpr:
-			return &object.SetExpr{Object: e.Object, Name: e.Name, Value: value}
-		case *object.IndexExpr:
-			return &object.SetExpr{Object: e, Name: nil, Value: value, IsIndex: true}
+		if target := p.assignTarget(expr, value); target != nil {
+			return target
 		}
 
 		p.addError(equals, "Invalid assignment target.")
 		return nil
 	}
 
-	if p.match(lexer.MinusEq, lexer.PlusEq, lexer.StarEq, lexer.SlashEq, lexer.PercentEq, lexer.TildSlashEq) {
+	if p.match(lexer.MinusEq, lexer.PlusEq, lexer.StarEq, lexer.SlashEq, lexer.PercentEq, lexer.TildSlashEq,
+		lexer.AmpEq, lexer.PipeEq, lexer.CaretEq, lexer.LessLessEq, lexer.GreaterGreaterEq, lexer.StarStarEq) {
 		equals := p.prevTok
 		value := p.assignment()
 
@@ -606,21 +1192,115 @@ func (p *Parser) assignment() object.Expr {
 			oper = lexer.NewToken(lexer.Percent, "%", equals.Filename, equals.Line)
 		case lexer.TildSlashEq:
 			oper = lexer.NewToken(lexer.TildSlash, "~/", equals.Filename, equals.Line)
+		case lexer.AmpEq:
+			oper = lexer.NewToken(lexer.Amp, "&", equals.Filename, equals.Line)
+		case lexer.PipeEq:
+			oper = lexer.NewToken(lexer.Pipe, "|", equals.Filename, equals.Line)
+		case lexer.CaretEq:
+			oper = lexer.NewToken(lexer.Caret, "^", equals.Filename, equals.Line)
+		case lexer.LessLessEq:
+			oper = lexer.NewToken(lexer.LessLess, "<<", equals.Filename, equals.Line)
+		case lexer.GreaterGreaterEq:
+			oper = lexer.NewToken(lexer.GreaterGreater, ">>", equals.Filename, equals.Line)
+		case lexer.StarStarEq:
+			oper = lexer.NewToken(lexer.StarStar, "**", equals.Filename, equals.Line)
 		default:
 			return nil
 		}
 
 		be := &object.BinaryExpr{Left: expr, Operator: oper, Right: value}
-		switch e := expr.(type) {
-		case *object.VariableExpr:
-			ae := &object.AssignExpr{Name: e.Name, Value: be}
-			p.resolve.Local(ae, ae.Name)
-			return ae
-		case *object.GetExpr:
-			return &object.SetExpr{Object: e.Object, Name: e.Name, Value: be}
-		case *object.IndexExpr:
-			return &object.SetExpr{Object: e, Name: nil, Value: be, IsIndex: true}
+		if target := p.assignTarget(expr, be); target != nil {
+			return target
+		}
+	}
+
+	return expr
+}
+
+// yieldExpr parses a yield, which suspends the generator it is in with the value after it, or null when there is
+// none. A function containing a yield is a generator.
+func (p *Parser) yieldExpr() object.Expr {
+	keyword := p.prevTok
+
+	var value object.Expr
+	switch p.curTok.Type {
+	case lexer.Semicolon, lexer.RParen, lexer.RBracket, lexer.RBrace, lexer.Comma, lexer.Colon, lexer.EOF:
+		value = &object.NullExpr{Token: lexer.NewToken(lexer.Null, "null", keyword.Filename, keyword.Line), Value: nil}
+	default:
+		if value = p.assignment(); value == nil {
+			return nil
+		}
+	}
+
+	switch {
+	case p.curFn == ftNone:
+		p.addMemberError(keyword, "Cannot use 'yield' outside of a function.")
+	case p.curFn == ftInit:
+		p.addMemberError(keyword, "Cannot use 'yield' in an initializer.")
+	case p.inParams:
+		p.addMemberError(keyword, "Cannot use 'yield' in a parameter default.")
+	}
+	p.yields = true
+
+	return &object.YieldExpr{Keyword: keyword, Value: value}
+}
+
+// assignTarget returns the expression assigning value to the variable, property or element expr refers to, or nil
+// if expr cannot be assigned to.
+func (p *Parser) assignTarget(expr object.Expr, value object.Expr) object.Expr {
+	switch e := expr.(type) {
+	case *object.VariableExpr:
+		p.checkConst(e.Name)
+		ae := &object.AssignExpr{Name: e.Name, Value: value, Type: p.resolve.TypeOf(e.Name)}
+		p.resolve.Local(ae, ae.Name)
+		return ae
+	case *object.GetExpr:
+		return &object.SetExpr{Object: e.Object, Name: e.Name, Value: value}
+	case *object.IndexExpr:
+		return &object.SetExpr{Object: e, Name: nil, Value: value, IsIndex: true}
+	}
+	return nil
+}
+
+// checkConst reports an assignment to name if it is a constant in scope.
+func (p *Parser) checkConst(name *lexer.Token) {
+	if p.resolve.IsConst(name) {
+		p.addMemberError(name, "Cannot assign to a constant.")
+	}
+}
+
+// conditional parses a conditional expression, cond ? a : b. It is right associative, so that a chain of them reads
+// like an if and else if chain.
+func (p *Parser) conditional() object.Expr {
+	expr := p.coalesce()
+
+	if p.match(lexer.Question) {
+		question := p.prevTok
+		then := p.expression()
+		if then == nil {
+			return nil
 		}
+		if !p.consume(lexer.Colon, "Expect ':' after then branch of conditional expression.") {
+			return nil
+		}
+		otherwise := p.conditional()
+		if otherwise == nil {
+			return nil
+		}
+		expr = &object.ConditionalExpr{Condition: expr, Question: question, Then: then, Else: otherwise}
+	}
+
+	return expr
+}
+
+// coalesce parses a ?? b, which is a unless it is null, in which case b is evaluated.
+func (p *Parser) coalesce() object.Expr {
+	expr := p.or()
+
+	for p.match(lexer.QuestionQuestion) {
+		oper := p.prevTok
+		right := p.or()
+		expr = &object.LogicalExpr{Left: expr, Operator: oper, Right: right}
 	}
 
 	return expr
@@ -672,13 +1352,95 @@ func (p *Parser) equality() object.Expr {
 }
 
 func (p *Parser) comparison() object.Expr {
-	expr := p.addition()
+	expr := p.bitOr()
 
 	for p.match(lexer.Greater, lexer.GreaterEq, lexer.Less, lexer.LessEq) {
 		if expr == nil {
 			return nil
 		}
 
+		oper := p.prevTok
+		right := p.bitOr()
+		if right == nil {
+			return nil
+		}
+
+		expr = &object.BinaryExpr{Left: expr, Operator: oper, Right: right}
+	}
+
+	return expr
+}
+
+// bitOr parses the bitwise operators, which bind more tightly than the comparisons so that flags & MASK == 0 tests
+// the masked bits. From loosest to tightest they are |, ^, & and then the shifts.
+func (p *Parser) bitOr() object.Expr {
+	expr := p.bitXor()
+
+	for p.match(lexer.Pipe) {
+		if expr == nil {
+			return nil
+		}
+
+		oper := p.prevTok
+		right := p.bitXor()
+		if right == nil {
+			return nil
+		}
+
+		expr = &object.BinaryExpr{Left: expr, Operator: oper, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitXor() object.Expr {
+	expr := p.bitAnd()
+
+	for p.match(lexer.Caret) {
+		if expr == nil {
+			return nil
+		}
+
+		oper := p.prevTok
+		right := p.bitAnd()
+		if right == nil {
+			return nil
+		}
+
+		expr = &object.BinaryExpr{Left: expr, Operator: oper, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) bitAnd() object.Expr {
+	expr := p.shift()
+
+	for p.match(lexer.Amp) {
+		if expr == nil {
+			return nil
+		}
+
+		oper := p.prevTok
+		right := p.shift()
+		if right == nil {
+			return nil
+		}
+
+		expr = &object.BinaryExpr{Left: expr, Operator: oper, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) shift() object.Expr {
+	expr := p.addition()
+
+	for p.match(lexer.LessLess, lexer.GreaterGreater) {
+		if expr == nil {
+			return nil
+		}
+
 		oper := p.prevTok
 		right := p.addition()
 		if right == nil {
@@ -732,7 +1494,7 @@ func (p *Parser) multiplication() object.Expr {
 }
 
 func (p *Parser) unary() object.Expr {
-	if p.match(lexer.Bang, lexer.Minus) {
+	if p.match(lexer.Bang, lexer.Minus, lexer.Tild) {
 		oper := p.prevTok
 		right := p.unary()
 		if right == nil {
@@ -741,48 +1503,164 @@ func (p *Parser) unary() object.Expr {
 		return &object.UnaryExpr{Operator: oper, Right: right}
 	}
 
-	return p.call()
+	if p.match(lexer.PlusPlus, lexer.MinusMinus) {
+		oper := p.prevTok
+		target := p.call()
+		if target == nil {
+			return nil
+		}
+		return p.update(oper, target, true)
+	}
+
+	// spawn is only a keyword when followed by the function to call, so it may still be used as a name.
+	next := p.l.PeekToken()
+	if p.check(lexer.Ident) && p.curTok.Lexeme == "spawn" && next != nil && (next.Type == lexer.Ident || next.Type == lexer.This || next.Type == lexer.Super) {
+		p.nextToken()
+		return p.spawn()
+	}
+
+	return p.power()
+}
+
+// spawn parses a call to run as a new task, after 'spawn'.
+func (p *Parser) spawn() object.Expr {
+	keyword := p.prevTok
+	expr := p.call()
+	if expr == nil {
+		return nil
+	}
+
+	call, ok := expr.(*object.CallExpr)
+	if !ok {
+		p.addMemberError(keyword, "Expect a call after 'spawn'.")
+		return expr
+	}
+	return &object.SpawnExpr{Keyword: keyword, Call: call}
+}
+
+// power parses a ** b. It binds more tightly than a unary operator on its left, and is right associative, so -2 ** 2
+// is -4 and 2 ** 3 ** 2 is 2 ** 9.
+func (p *Parser) power() object.Expr {
+	expr := p.postfix()
+
+	if p.match(lexer.StarStar) {
+		if expr == nil {
+			return nil
+		}
+
+		oper := p.prevTok
+		right := p.unary()
+		if right == nil {
+			return nil
+		}
+
+		expr = &object.BinaryExpr{Left: expr, Operator: oper, Right: right}
+	}
+
+	return expr
+}
+
+func (p *Parser) postfix() object.Expr {
+	expr := p.call()
+
+	if expr != nil && p.match(lexer.PlusPlus, lexer.MinusMinus) {
+		return p.update(p.prevTok, expr, false)
+	}
+
+	return expr
+}
+
+// update returns the expression for ++ or -- applied to target, which assigns target one more or less than its value.
+func (p *Parser) update(oper *lexer.Token, target object.Expr, prefix bool) object.Expr {
+	op := lexer.NewToken(lexer.Plus, "+", oper.Filename, oper.Line)
+	if oper.Type == lexer.MinusMinus {
+		op = lexer.NewToken(lexer.Minus, "-", oper.Filename, oper.Line)
+	}
+	one := &object.NumberExpr{Token: lexer.NewToken(lexer.NumberI, "1", oper.Filename, oper.Line), Int: 1}
+
+	assign := p.assignTarget(target, &object.BinaryExpr{Left: target, Operator: op, Right: one})
+	if assign == nil {
+		p.addMemberError(oper, "Invalid "+oper.Lexeme+" target.")
+		return nil
+	}
+	return &object.UpdateExpr{Operator: oper, Target: target, Assign: assign, Prefix: prefix}
 }
 
+// call parses a chain of calls, indexes and property accesses. If the chain contains a ?. it is wrapped in an
+// OptionalExpr, so that the rest of the chain is skipped when the object before the ?. is null.
 func (p *Parser) call() object.Expr {
-	expr := p.index()
+	expr := p.primary()
+	optional := false
 
 	for {
 		if p.match(lexer.LParen) {
 			expr = p.finishCall(expr)
+		} else if p.match(lexer.LBracket) {
+			expr = p.finishIndex(expr)
+			if expr == nil {
+				return nil
+			}
 		} else if p.match(lexer.Dot) {
 			if !p.consume(lexer.Ident, "Expect property name after '.'.") {
 				return nil
 			}
 			name := p.prevTok
 			expr = &object.GetExpr{Object: expr, Name: name}
+		} else if p.match(lexer.QuestionDot) {
+			if !p.consume(lexer.Ident, "Expect property name after '?.'.") {
+				return nil
+			}
+			expr = &object.GetExpr{Object: expr, Name: p.prevTok, Optional: true}
+			optional = true
 		} else {
 			break
 		}
 	}
 
+	if optional {
+		return &object.OptionalExpr{Expression: expr}
+	}
 	return expr
 }
 
+// finishCall parses the arguments of a call. An argument written as name: value is passed to the parameter name,
+// and must follow any positional arguments.
 func (p *Parser) finishCall(callee object.Expr) object.Expr {
 	var args []object.Expr
+	var names []*lexer.Token
+	named := false
 
 	if !p.check(lexer.RParen) {
-		a := p.expression()
-		if a == nil {
-			return nil
-		}
-
-		args = append(args, a)
-		for p.match(lexer.Comma) {
+		for {
 			if len(args) > 32 {
 				p.addError(p.curTok, "Cannot have more than 32 arguments.")
 			}
-			a = p.expression()
+
+			var name *lexer.Token
+			if next := p.l.PeekToken(); p.check(lexer.Ident) && next != nil && next.Type == lexer.Colon {
+				name = p.curTok
+				p.nextToken()
+				p.nextToken()
+				for _, other := range names {
+					if other != nil && other.Lexeme == name.Lexeme {
+						p.addMemberError(name, "Argument "+name.Lexeme+" is passed more than once.")
+					}
+				}
+				named = true
+			} else if named {
+				p.addMemberError(p.curTok, "Positional arguments cannot follow named arguments.")
+			}
+
+			a := p.expression()
 			if a == nil {
 				return nil
 			}
 			args = append(args, a)
+			names = append(names, name)
+
+			if !p.match(lexer.Comma) {
+				break
+			}
 		}
 	}
 
@@ -790,27 +1668,25 @@ func (p *Parser) finishCall(callee object.Expr) object.Expr {
 		return nil
 	}
 
-	return &object.CallExpr{Callee: callee, Paren: p.prevTok, Args: args}
+	// Names is only kept when at least one argument is named.
+	if !named {
+		names = nil
+	}
+	return &object.CallExpr{Callee: callee, Paren: p.prevTok, Args: args, Names: names}
 }
 
-func (p *Parser) index() object.Expr {
-	expr := p.primary()
-
-	for p.match(lexer.LBracket) {
-		if expr == nil {
-			return nil
-		}
-
-		oper := p.prevTok
-		right := p.expression()
-		if !p.consume(lexer.RBracket, "Expect ']' after index.") {
-			return nil
-		}
+func (p *Parser) finishIndex(left object.Expr) object.Expr {
+	if left == nil {
+		return nil
+	}
 
-		expr = &object.IndexExpr{Left: expr, Operator: oper, Right: right}
+	oper := p.prevTok
+	right := p.expression()
+	if !p.consume(lexer.RBracket, "Expect ']' after index.") {
+		return nil
 	}
 
-	return expr
+	return &object.IndexExpr{Left: left, Operator: oper, Right: right}
 }
 
 func (p *Parser) primary() object.Expr {
@@ -825,11 +1701,16 @@ func (p *Parser) primary() object.Expr {
 		return p.parseNumber()
 	case p.match(lexer.String, lexer.RawString):
 		return &object.StringExpr{Token: p.prevTok, Value: p.prevTok.Lexeme}
+	case p.match(lexer.Interpolation):
+		return p.interpolation()
 	case p.match(lexer.This):
 		return p.thisCall()
 	case p.match(lexer.UTString):
 		p.addError(p.prevTok, "Unterminated string.")
 		return nil
+	case p.match(lexer.BadEscape):
+		p.addError(p.prevTok, "Invalid escape sequence in string.")
+		return nil
 	case p.match(lexer.Super):
 		return p.superCall()
 	case p.match(lexer.LBracket):
@@ -860,6 +1741,49 @@ func (p *Parser) primary() object.Expr {
 	return nil
 }
 
+func (p *Parser) interpolation() object.Expr {
+	token := p.prevTok
+	var parts []object.Expr
+
+	for {
+		if p.prevTok.Lexeme != "" {
+			parts = append(parts, &object.StringExpr{Token: p.prevTok, Value: p.prevTok.Lexeme})
+		}
+
+		expr := p.expression()
+		if expr == nil {
+			return nil
+		}
+		parts = append(parts, expr)
+
+		if p.match(lexer.Interpolation) {
+			continue
+		}
+
+		if p.match(lexer.String) {
+			if p.prevTok.Lexeme != "" {
+				parts = append(parts, &object.StringExpr{Token: p.prevTok, Value: p.prevTok.Lexeme})
+			}
+			break
+		}
+
+		if p.match(lexer.UTString) {
+			p.addError(token, "Unterminated string.")
+			return nil
+		}
+
+		if p.match(lexer.BadEscape) {
+			p.addError(p.prevTok, "Invalid escape sequence in string.")
+			return nil
+		}
+
+		p.addError(p.curTok, "Expect '}' after interpolated expression.")
+		return nil
+	}
+
+	return &object.InterpolationExpr{Token: token, Parts: parts}
+}
+
 func (p *Parser) superCall() object.Expr {
 	keyword := p.prevTok
 	if !p.consume(lexer.Dot, "Expect '.' after 'super'.") {
@@ -911,8 +1835,9 @@ func (p *Parser) variable() object.Expr {
 
 func (p *Parser) parseNumber() *object.NumberExpr {
 	tok := p.prevTok
+	lexeme := strings.Replace(tok.Lexeme, "_", "", -1)
 	if tok.Type == lexer.NumberF {
-		n, err := strconv.ParseFloat(p.prevTok.Lexeme, 64)
+		n, err := strconv.ParseFloat(lexeme, 64)
 		if err != nil {
 			p.addError(p.prevTok, "Unable to parse value: "+p.prevTok.Lexeme+".")
 			return nil
@@ -920,12 +1845,24 @@ func (p *Parser) parseNumber() *object.NumberExpr {
 		return &object.NumberExpr{Token: tok, Float: n}
 	}
 
-	n, err := strconv.ParseInt(p.prevTok.Lexeme, 10, 64)
-	if err != nil {
-		p.addError(p.prevTok, "Unable to parse value: "+p.prevTok.Lexeme+".")
-		return nil
+	// Only use the base prefix when there is one, so that a leading zero does not make a decimal literal octal.
+	base := 10
+	if len(lexeme) > 1 && lexeme[0] == '0' && strings.ContainsAny(lexeme[1:2], "xXoObB") {
+		base = 0
+	}
+
+	n, err := strconv.ParseInt(lexeme, base, strconv.IntSize)
+	if err == nil {
+		return &object.NumberExpr{Token: tok, Int: int(n)}
 	}
-	return &object.NumberExpr{Token: p.prevTok, Int: int(n)}
+
+	// Literals too large for an int are kept exactly.
+	if b, ok := new(big.Int).SetString(lexeme, base); ok {
+		return &object.NumberExpr{Token: tok, Big: b}
+	}
+
+	p.addError(p.prevTok, "Unable to parse value: "+p.prevTok.Lexeme+".")
+	return nil
 }
 
 func (p *Parser) synchronize() {
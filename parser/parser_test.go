@@ -193,6 +193,169 @@ func TestClassStatement(t *testing.T) {
 	}
 }
 
+func TestClassMembers(t *testing.T) {
+	input := `class Mob {
+  static var all = [];
+  var hp = 10;
+  var loot;
+  static spawn(name) { return this(); }
+  get alive() { return this.hp > 0; }
+  set hp(v) { this._hp = v; }
+  get(key) { return key; }
+}`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	cs, ok := stmts[0].(*object.ClassStmt)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*object.ClassStmt, got=%T", stmts[0])
+	}
+
+	if len(cs.StaticFields) != 1 || cs.StaticFields[0].Name.Lexeme != "all" {
+		t.Errorf("wrong static fields. expected=[all], got=%v", cs.StaticFields)
+	}
+
+	if len(cs.Fields) != 2 {
+		t.Fatalf("wrong number of fields. expected=%d, got=%d", 2, len(cs.Fields))
+	}
+	testLiteralExpression(t, cs.Fields[0].Value, 10)
+	if cs.Fields[1].Value != nil {
+		t.Errorf("field without a default has a value. got=%v", cs.Fields[1].Value)
+	}
+
+	if len(cs.StaticMethods) != 1 || cs.StaticMethods[0].Name.Lexeme != "spawn" {
+		t.Errorf("wrong static methods. expected=[spawn], got=%v", cs.StaticMethods)
+	}
+	if len(cs.Getters) != 1 || cs.Getters[0].Name.Lexeme != "alive" {
+		t.Errorf("wrong getters. expected=[alive], got=%v", cs.Getters)
+	}
+	if len(cs.Setters) != 1 || cs.Setters[0].Name.Lexeme != "hp" {
+		t.Errorf("wrong setters. expected=[hp], got=%v", cs.Setters)
+	}
+
+	// get and set are ordinary method names when not followed by a property name.
+	if len(cs.Methods) != 1 || cs.Methods[0].Name.Lexeme != "get" {
+		t.Errorf("wrong methods. expected=[get], got=%v", cs.Methods)
+	}
+}
+
+func TestTraitStatement(t *testing.T) {
+	input := `trait Lootable { var items = []; drop() { return super.drop(); } get count() { return 0; } }
+class Orc : Mob with Aggressive, Lootable {}`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	if len(stmts) != 2 {
+		t.Fatalf("incorrect number of statements. expected=%d, got=%d", 2, len(stmts))
+	}
+
+	ts, ok := stmts[0].(*object.TraitStmt)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*object.TraitStmt, got=%T", stmts[0])
+	}
+	if ts.Name.Lexeme != "Lootable" {
+		t.Errorf("trait name wrong. expected=%q, got=%q", "Lootable", ts.Name.Lexeme)
+	}
+	if len(ts.Fields) != 1 || len(ts.Methods) != 1 || len(ts.Getters) != 1 {
+		t.Errorf("wrong trait members. expected 1 field, method and getter, got=%d, %d, %d", len(ts.Fields), len(ts.Methods), len(ts.Getters))
+	}
+
+	cs, ok := stmts[1].(*object.ClassStmt)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*object.ClassStmt, got=%T", stmts[1])
+	}
+	if cs.Super.Name.Lexeme != "Mob" {
+		t.Errorf("Superclass name wrong. expected=%q, got=%q", "Mob", cs.Super.Name.Lexeme)
+	}
+	if len(cs.Traits) != 2 || cs.Traits[0].Name.Lexeme != "Aggressive" || cs.Traits[1].Name.Lexeme != "Lootable" {
+		t.Errorf("wrong traits. expected=[Aggressive Lootable], got=%v", cs.Traits)
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	input := `class Mob { var hp: int = 10; static var boss: Mob?; }
+fn hit(target: Mob, dmg, f: fn): bool {
+  var dead: bool;
+  dead = target.hp <= dmg;
+  return dead;
+}`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	cs := stmts[0].(*object.ClassStmt)
+	if got := cs.Fields[0].Type; got == nil || got.String() != "int" {
+		t.Errorf("wrong field type. expected=%q, got=%v", "int", got)
+	}
+	if got := cs.StaticFields[0].Type; got == nil || got.String() != "Mob?" {
+		t.Errorf("wrong static field type. expected=%q, got=%v", "Mob?", got)
+	}
+
+	fn := stmts[1].(*object.FunctionStmt)
+	expected := []string{"Mob", "", "fn"}
+	if len(fn.ParamTypes) != len(expected) {
+		t.Fatalf("wrong number of parameter types. expected=%d, got=%d", len(expected), len(fn.ParamTypes))
+	}
+	for i, want := range expected {
+		got := fn.ParamTypes[i]
+		if want == "" && got != nil || want != "" && (got == nil || got.String() != want) {
+			t.Errorf("wrong type for parameter %d. expected=%q, got=%v", i, want, got)
+		}
+	}
+	if fn.ReturnType == nil || fn.ReturnType.String() != "bool" {
+		t.Errorf("wrong return type. expected=%q, got=%v", "bool", fn.ReturnType)
+	}
+
+	vs := fn.Body[0].(*object.VarStmt)
+	if vs.Type == nil || vs.Type.String() != "bool" {
+		t.Errorf("wrong variable type. expected=%q, got=%v", "bool", vs.Type)
+	}
+
+	// Assignments carry the type the variable was declared with.
+	ae := fn.Body[1].(*object.ExpressionStmt).Expression.(*object.AssignExpr)
+	if ae.Type != vs.Type {
+		t.Errorf("assignment has wrong type. expected=%v, got=%v", vs.Type, ae.Type)
+	}
+
+	// Functions without annotations have no parameter types.
+	l = lexer.New([]byte("fn add(a, b) { return a + b; }"), "testfile.gpc")
+	p = New(l)
+	stmts, _ = p.Parse()
+	checkParseErrors(t, p)
+	if fn := stmts[0].(*object.FunctionStmt); fn.ParamTypes != nil || fn.ReturnType != nil {
+		t.Errorf("untyped function has types. got=%v, %v", fn.ParamTypes, fn.ReturnType)
+	}
+}
+
+func TestParameters(t *testing.T) {
+	input := `fn log(level, prefix = "> ", ...parts) { }
+fn test() { log("info", prefix: "", parts: 1); }`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	fn := stmts[0].(*object.FunctionStmt)
+	if len(fn.Parameters) != 3 || !fn.Variadic {
+		t.Fatalf("wrong parameters. expected 3 ending with a rest parameter, got=%d variadic=%t", len(fn.Parameters), fn.Variadic)
+	}
+	if len(fn.Defaults) != 3 || fn.Defaults[0] != nil || fn.Defaults[2] != nil {
+		t.Fatalf("wrong defaults. got=%v", fn.Defaults)
+	}
+	testStringLiteral(t, fn.Defaults[1], "> ")
+
+	call := stmts[1].(*object.FunctionStmt).Body[0].(*object.ExpressionStmt).Expression.(*object.CallExpr)
+	if len(call.Names) != 3 || call.Names[0] != nil || call.Names[1].Lexeme != "prefix" || call.Names[2].Lexeme != "parts" {
+		t.Errorf("wrong argument names. got=%v", call.Names)
+	}
+	testStringLiteral(t, call.Args[1], "")
+}
+
 func TestContinueStatement(t *testing.T) {
 	input := `fn test() { while (true) continue; }`
 	l := lexer.New([]byte(input), "testfile.gpc")
@@ -276,6 +439,55 @@ else
 	}
 }
 
+func TestMatchStatement(t *testing.T) {
+	input := `fn test(cmd) {
+  match (cmd) {
+    case "n", -1 => go();
+    case [verb, ...rest] if verb == "say" => say(rest);
+    case Room? r => look(r);
+    default => { }
+  }
+}`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	ms, ok := stmts[0].(*object.FunctionStmt).Body[0].(*object.MatchStmt)
+	if !ok {
+		t.Fatalf("Statement wrong type. expected=*object.MatchStmt, got=%T", stmts[0].(*object.FunctionStmt).Body[0])
+	}
+	if !testIdentifier(t, ms.Subject, "cmd") || len(ms.Cases) != 4 {
+		t.Fatalf("wrong match statement. expected 4 cases, got=%d", len(ms.Cases))
+	}
+
+	lits := ms.Cases[0].Patterns
+	if len(lits) != 2 || lits[0].Kind != object.LiteralPattern || lits[1].Kind != object.LiteralPattern {
+		t.Fatalf("wrong literal patterns. got=%+v", lits)
+	}
+	testStringLiteral(t, lits[0].Value, "n")
+	if _, ok := lits[1].Value.(*object.UnaryExpr); !ok {
+		t.Errorf("negative literal wrong type. expected=*object.UnaryExpr, got=%T", lits[1].Value)
+	}
+
+	list := ms.Cases[1].Patterns[0]
+	if list.Kind != object.ListPattern || len(list.Elements) != 1 || list.Elements[0].Name.Lexeme != "verb" || list.Rest.Lexeme != "rest" {
+		t.Errorf("wrong list pattern. got=%+v", list)
+	}
+	if ms.Cases[1].Guard == nil {
+		t.Errorf("missing guard")
+	}
+
+	typ := ms.Cases[2].Patterns[0]
+	if typ.Kind != object.TypePattern || typ.Type.String() != "Room?" || typ.Name.Lexeme != "r" {
+		t.Errorf("wrong type pattern. got=%+v", typ)
+	}
+
+	if ms.Cases[3].Keyword.Type != lexer.Default || ms.Cases[3].Patterns != nil {
+		t.Errorf("wrong default case. got=%+v", ms.Cases[3])
+	}
+}
+
 func TestImportStatement(t *testing.T) {
 	tests := []struct {
 		input string
@@ -445,6 +657,80 @@ func TestForStatement(t *testing.T) {
 	testBinaryExpression(t, ae.Value, "i", "+", 1)
 }
 
+func TestForInStatement(t *testing.T) {
+	input := `fn test(rooms) { for (var room in rooms) { debugPrint(room); } }`
+
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	fn := stmts[0].(*object.FunctionStmt)
+	fs, ok := fn.Body[0].(*object.ForInStmt)
+	if !ok {
+		t.Fatalf("statement wrong type. expected=*object.ForInStmt, got=%T", fn.Body[0])
+	}
+
+	if fs.Name.Lexeme != "room" {
+		t.Errorf("loop variable incorrect. expected=%q, got=%q", "room", fs.Name.Lexeme)
+	}
+	testIdentifier(t, fs.Iterable, "rooms")
+
+	bl, ok := fs.Body.(*object.BlockStmt)
+	if !ok {
+		t.Fatalf("body wrong type. expected=*object.BlockStmt, got=%T", fs.Body)
+	}
+	if len(bl.Statements) != 1 {
+		t.Errorf("wrong number of statements in body. expected=%d, got=%d", 1, len(bl.Statements))
+	}
+}
+
+func TestYieldExpression(t *testing.T) {
+	tests := []struct {
+		input     string
+		generator bool
+		value     interface{}
+	}{
+		{"fn x() { yield 1; }", true, 1},
+		{"fn x() { yield; }", true, nil},
+		{"fn x() { var y = yield x; }", true, "x"},
+		{"fn x() { fn y() { yield; } }", false, nil},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New([]byte(tt.input), "testfile.gpc")
+		p := New(l)
+		stmts, _ := p.Parse()
+		checkParseErrors(t, p)
+
+		fn := stmts[0].(*object.FunctionStmt)
+		if fn.Generator != tt.generator {
+			t.Errorf("test %d: generator incorrect. expected=%t, got=%t", i+1, tt.generator, fn.Generator)
+		}
+		if !tt.generator {
+			if inner := fn.Body[0].(*object.FunctionStmt); !inner.Generator {
+				t.Errorf("test %d: inner function is not a generator", i+1)
+			}
+			continue
+		}
+
+		var value object.Expr
+		switch st := fn.Body[0].(type) {
+		case *object.ExpressionStmt:
+			value = st.Expression
+		case *object.VarStmt:
+			value = st.Value
+		}
+
+		ye, ok := value.(*object.YieldExpr)
+		if !ok {
+			t.Errorf("test %d: wrong expression type. expected=*object.YieldExpr, got=%T", i+1, value)
+			continue
+		}
+		testLiteralExpression(t, ye.Value, tt.value)
+	}
+}
+
 func TestDoWhileStatement(t *testing.T) {
 	input := `fn test() { do {
   error += "Hello";
@@ -624,6 +910,54 @@ func TestVarStatement(t *testing.T) {
 	}
 }
 
+func TestDestructuring(t *testing.T) {
+	input := `fn test() {
+  var [a, ...rest] = list;
+  const {name, level} = player;
+  a, b.c, d[0] = 1, 2, 3;
+  return a, name;
+}`
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	body := stmts[0].(*object.FunctionStmt).Body
+	if len(body) != 4 {
+		t.Fatalf("incorrect number of statements. expected=%d, got=%d", 4, len(body))
+	}
+
+	ds := body[0].(*object.DestructureStmt)
+	if ds.Keyword.Type != lexer.LBracket || len(ds.Names) != 1 || ds.Names[0].Lexeme != "a" || ds.Rest.Lexeme != "rest" || ds.Const {
+		t.Errorf("wrong list destructuring. got=%+v", ds)
+	}
+	testIdentifier(t, ds.Value, "list")
+
+	ds = body[1].(*object.DestructureStmt)
+	if ds.Keyword.Type != lexer.LBrace || len(ds.Names) != 2 || ds.Names[1].Lexeme != "level" || ds.Rest != nil || !ds.Const {
+		t.Errorf("wrong property destructuring. got=%+v", ds)
+	}
+
+	ma := body[2].(*object.MultiAssignStmt)
+	if len(ma.Targets) != 3 || len(ma.Values) != 3 {
+		t.Fatalf("wrong number of targets and values. got=%d and %d", len(ma.Targets), len(ma.Values))
+	}
+	if _, ok := ma.Targets[0].(*object.AssignExpr); !ok {
+		t.Errorf("target 1 wrong type. expected=*object.AssignExpr, got=%T", ma.Targets[0])
+	}
+	if se, ok := ma.Targets[1].(*object.SetExpr); !ok || se.IsIndex {
+		t.Errorf("target 2 wrong type. expected=*object.SetExpr, got=%T", ma.Targets[1])
+	}
+	if se, ok := ma.Targets[2].(*object.SetExpr); !ok || !se.IsIndex {
+		t.Errorf("target 3 wrong type. expected=*object.SetExpr of an index, got=%T", ma.Targets[2])
+	}
+
+	ret := body[3].(*object.ReturnStmt)
+	if list, ok := ret.Value.(*object.ListExpr); !ok || len(list.Values) != 2 {
+		t.Errorf("return value wrong type. expected=*object.ListExpr of 2 values, got=%T", ret.Value)
+	}
+}
+
 func TestAssignExpression(t *testing.T) {
 	tests := []struct {
 		input string
@@ -898,6 +1232,36 @@ func TestIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestInterpolationExpression(t *testing.T) {
+	input := `var x = "You have ${count} coins and ${items[0]}";`
+
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	if len(stmts) != 1 {
+		t.Fatalf("incorrect number of statements. expected=%d, got=%d", 1, len(stmts))
+	}
+
+	stmt := stmts[0].(*object.VarStmt)
+	ie, ok := stmt.Value.(*object.InterpolationExpr)
+	if !ok {
+		t.Fatalf("expression wrong type. expected=*object.InterpolationExpr, got=%T", stmt.Value)
+	}
+
+	if len(ie.Parts) != 4 {
+		t.Fatalf("wrong number of parts. expected=%d, got=%d", 4, len(ie.Parts))
+	}
+
+	testStringLiteral(t, ie.Parts[0], "You have ")
+	testIdentifier(t, ie.Parts[1], "count")
+	testStringLiteral(t, ie.Parts[2], " coins and ")
+	if _, ok := ie.Parts[3].(*object.IndexExpr); !ok {
+		t.Errorf("part wrong type. expected=*object.IndexExpr, got=%T", ie.Parts[3])
+	}
+}
+
 func TestListExpression(t *testing.T) {
 	input := "var x = [0, 'one', true];"
 
@@ -966,6 +1330,14 @@ func TestNumberLiteralExpression(t *testing.T) {
 		{"var x = 5;", 5},
 		{"var x = 10;", 10},
 		{"var x = 123.456;", 123.456},
+		{"var x = 0x1F;", 31},
+		{"var x = 0o17;", 15},
+		{"var x = 0b101;", 5},
+		{"var x = 010;", 10},
+		{"var x = 1_000_000;", 1000000},
+		{"var x = 1.5e3;", 1500.0},
+		{"var x = 2E-2;", 0.02},
+		{"var x = 1e2;", 100.0},
 	}
 
 	for i, tt := range tests {
@@ -990,6 +1362,25 @@ func TestNumberLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigNumberLiteralExpression(t *testing.T) {
+	input := "var x = 0x1_0000_0000_0000_0000;"
+
+	l := lexer.New([]byte(input), "testfile.gpc")
+	p := New(l)
+	stmts, _ := p.Parse()
+	checkParseErrors(t, p)
+
+	s := stmts[0].(*object.VarStmt)
+	ne, ok := s.Value.(*object.NumberExpr)
+	if !ok {
+		t.Fatalf("expr not correct type. expected=*object.NumberExpr, got=%T", s.Value)
+	}
+
+	if ne.Big == nil || ne.Big.String() != "18446744073709551616" {
+		t.Errorf("big value did not match. expected=%s, got=%v", "18446744073709551616", ne.Big)
+	}
+}
+
 func TestNullLiteralExpression(t *testing.T) {
 	input := "var x = null;"
 
@@ -1068,6 +1459,42 @@ func TestParserErrors(t *testing.T) {
 		{`import "test.gpc"`, 1, "at end", "Expect ';' after import statement."},
 		{"import test.gpc", 2, "test", "Expect string after import keyword."},
 		{"var x = 7; import `test.gpc`;", 1, "import", "Import statements must appear at the beginning of a file."},
+		{`fn test() { "a ${b c}"; }`, 2, "c", "Expect '}' after interpolated expression."},
+		{"var x = 0x;", 1, "0x", "Unable to parse value: 0x."},
+		{`fn test() { "bad \q escape"; }`, 2, `\q`, "Invalid escape sequence in string."},
+		{`fn test() { "a ${b} \x1"; }`, 2, `\x1`, "Invalid escape sequence in string."},
+		{`fn test() { "a ${b} c; }`, 3, "a ", "Unterminated string."},
+		{"var x: = 1;", 1, "=", "Expect type name."},
+		{"fn test(a: 7) {}", 1, "7", "Expect type name."},
+		{"fn test(...a, b) {}", 1, ",", "Rest parameter must be the last parameter."},
+		{"fn test(...a = 1) {}", 1, "=", "Rest parameter cannot have a default value."},
+		{"fn test(a = 1, b) {}", 1, "b", "Parameter without a default value cannot follow one with a default value."},
+		{"fn test() { f(a: 1, 2); }", 1, "2", "Positional arguments cannot follow named arguments."},
+		{"fn test() { f(a: 1, a: 2); }", 1, "a", "Argument a is passed more than once."},
+		{"const x;", 1, ";", "Expect '=' after constant name."},
+		{"fn test() { const x = 1; x = 2; }", 1, "x", "Cannot assign to a constant."},
+		{"fn test() { const x = 1; x += 2; }", 1, "x", "Cannot assign to a constant."},
+		{"fn test() { match (x) { 1 => f(); } }", 2, "1", "Expect 'case' or 'default'."},
+		{"fn test() { match (x) { case 1 f(); } }", 2, "f", "Expect '=>' after 'case'."},
+		{"fn test() { match (x) { case [...a, b] => f(); } }", 2, ",", "The rest pattern must be the last element of a list pattern."},
+		{"fn test() { match (x) { case 1, a => f(); } }", 1, "a", "A case with several patterns cannot bind variables."},
+		{"fn test() { match (x) { case + => f(); } }", 2, "+", "Expect pattern."},
+		{"fn test() { var [a, b]; }", 1, ";", "Expect '=' after variable names."},
+		{"fn test() { var {a b} = x; }", 1, "b", "Expect '}' after variable names."},
+		{"fn test() { const [a, b] = x; a, b = b, a; }", 2, "a", "Cannot assign to a constant."},
+		{"fn test() { a, 1 = 1, 2; }", 1, "=", "Invalid assignment target."},
+		{"fn test() { a, b = 1, 2, 3; }", 1, "=", "Expected 2 values but got 3."},
+		{"var x = a ? b;", 1, ";", "Expect ':' after then branch of conditional expression."},
+		{"var x = a?.;", 1, ";", "Expect property name after '?.'."},
+		{"fn test() { a?.b = 1; }", 2, "=", "Invalid assignment target."},
+		{"fn test() { 5++; }", 1, "++", "Invalid ++ target."},
+		{"fn test() { --f(); }", 1, "--", "Invalid -- target."},
+		{"var x = yield 1;", 1, "yield", "Cannot use 'yield' outside of a function."},
+		{"class A { init() { yield; } }", 1, "yield", "Cannot use 'yield' in an initializer."},
+		{"fn test(a = yield) {}", 1, "yield", "Cannot use 'yield' in a parameter default."},
+		{"fn test() { for (var x in ) {} }", 2, ")", "Expect expression."},
+		{"fn test() { for (var x in y {} }", 2, "{", "Expect ')' after for-in clause."},
+		{"fn test() { spawn f; }", 1, "spawn", "Expect a call after 'spawn'."},
 	}
 
 	for i, tt := range tests {
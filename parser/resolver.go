@@ -6,8 +6,10 @@ import (
 )
 
 type Resolver struct {
-	stack []map[string]bool
-	dist  map[object.Expr]int
+	stack  []map[string]bool
+	types  []map[string]*object.TypeAnnotation // Declared types of the variables in each scope of stack.
+	consts []map[string]bool                   // Names in each scope of stack declared with const.
+	dist   map[object.Expr]int
 }
 
 func NewResolver() *Resolver {
@@ -16,6 +18,8 @@ func NewResolver() *Resolver {
 
 func (r *Resolver) Begin() {
 	r.stack = append(r.stack, make(map[string]bool))
+	r.types = append(r.types, make(map[string]*object.TypeAnnotation))
+	r.consts = append(r.consts, make(map[string]bool))
 }
 
 func (r *Resolver) End() {
@@ -24,6 +28,8 @@ func (r *Resolver) End() {
 	}
 
 	r.stack = r.stack[:len(r.stack) - 1]
+	r.types = r.types[:len(r.types) - 1]
+	r.consts = r.consts[:len(r.consts) - 1]
 }
 
 func (r *Resolver) Peek() map[string]bool {
@@ -57,6 +63,48 @@ func (r *Resolver) Define(name *lexer.Token) {
 	scope[name.Lexeme] = true
 }
 
+// SetType records the type annotation a variable was declared with in the innermost scope.
+func (r *Resolver) SetType(name *lexer.Token, t *object.TypeAnnotation) {
+	if len(r.types) == 0 || t == nil {
+		return
+	}
+
+	r.types[len(r.types) - 1][name.Lexeme] = t
+}
+
+// TypeOf returns the type annotation of the closest variable named name, or nil if it was declared without one or
+// is global.
+func (r *Resolver) TypeOf(name *lexer.Token) *object.TypeAnnotation {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if _, ok := r.stack[i][name.Lexeme]; ok {
+			return r.types[i][name.Lexeme]
+		}
+	}
+
+	return nil
+}
+
+// SetConst marks the variable declared in the innermost scope as a constant.
+func (r *Resolver) SetConst(name *lexer.Token) {
+	if len(r.consts) == 0 {
+		return
+	}
+
+	r.consts[len(r.consts) - 1][name.Lexeme] = true
+}
+
+// IsConst reports whether the closest variable named name was declared with const. Globals and names imported from
+// other files are not known, and are checked when they are assigned at runtime.
+func (r *Resolver) IsConst(name *lexer.Token) bool {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if _, ok := r.stack[i][name.Lexeme]; ok {
+			return r.consts[i][name.Lexeme]
+		}
+	}
+
+	return false
+}
+
 func (r *Resolver) Local(expr object.Expr, name *lexer.Token) {
 	for i := len(r.stack) - 1; i >= 0; i-- {
 		if _, ok := r.stack[i][name.Lexeme]; ok {
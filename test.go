@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"github.com/butlermatt/glpc/interpreter"
+	"github.com/butlermatt/glpc/lexer"
+	"github.com/butlermatt/glpc/object"
+	"github.com/butlermatt/glpc/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const testFileSuffix = "_test.glpc"
+
+// testSeed seeds the random module of every test so that test runs are repeatable.
+const testSeed = 1
+
+// runTests discovers the test files found in paths, runs every test in them and reports the results. It returns
+// the exit status for the process.
+func runTests(paths []string) int {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := findTestFiles(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if len(files) == 0 {
+		fmt.Println("no test files found")
+		return 0
+	}
+
+	start := time.Now()
+	var passed, failed int
+	for _, file := range files {
+		p, f := runTestFile(file)
+		passed += p
+		failed += f
+	}
+
+	status := "PASS"
+	if failed > 0 {
+		status = "FAIL"
+	}
+	fmt.Printf("%s\t%d passed, %d failed (%v)\n", status, passed, failed, time.Since(start).Round(time.Microsecond))
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// findTestFiles returns every file ending in testFileSuffix within paths. Directories are searched recursively and
+// files given explicitly are used as is.
+func findTestFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() && strings.HasSuffix(p, testFileSuffix) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// runTestFile runs each top-level function whose name begins with "test" in its own freshly interpreted copy of
+// the file. It returns the number of tests which passed and failed.
+func runTestFile(path string) (passed, failed int) {
+	fmt.Printf("=== %s\n", path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("--- FAIL: %s\n    %v\n", path, err)
+		return 0, 1
+	}
+
+	p := parser.New(lexer.New(data, path))
+	stmts, _ := p.Parse()
+	if errs := p.Errors(); len(errs) > 0 {
+		fmt.Printf("--- FAIL: %s\n", path)
+		for _, err := range errs {
+			fmt.Printf("    [Syntax error] %+v\n", err)
+		}
+		return 0, 1
+	}
+
+	for _, stmt := range stmts {
+		fn, ok := stmt.(*object.FunctionStmt)
+		if !ok || !strings.HasPrefix(fn.Name.Lexeme, "test") {
+			continue
+		}
+
+		start := time.Now()
+		err := runTest(data, path, fn.Name.Lexeme)
+		elapsed := time.Since(start).Round(time.Microsecond)
+		if err != nil {
+			failed++
+			fmt.Printf("--- FAIL: %s (%v)\n    %v\n", fn.Name.Lexeme, elapsed, err)
+		} else {
+			passed++
+			fmt.Printf("--- PASS: %s (%v)\n", fn.Name.Lexeme, elapsed)
+		}
+	}
+
+	return passed, failed
+}
+
+// runTest interprets the file with a new interpreter and calls the named test function.
+func runTest(input []byte, filename string, name string) error {
+	l := lexer.New(input, filename)
+	p := parser.New(l)
+	interp := interpreter.New()
+	interp.SetSeed(testSeed)
+	env, err := interp.Interpret(p, filename)
+	if err != nil {
+		return err
+	}
+
+	err = interp.RunFunction(env, name)
+	if errs := interp.Wait(); err == nil && len(errs) > 0 {
+		err = errs[0]
+	}
+	return err
+}